@@ -2,9 +2,13 @@
 package hq
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
 	"os"
 	"runtime"
-	"strconv"
+	"slices"
 	"time"
 
 	"paepcke.de/sphincs"
@@ -32,6 +36,7 @@ type HQ struct {
 	ID
 	// IO exchange
 	IO
+	ui *ui // report output
 }
 
 // ID identiy
@@ -54,12 +59,25 @@ type IO struct {
 	FileName        string               // Report FileName
 	FilesTotal      uint64               // total number of files
 	FilesFail       uint64               // total number of files with hash|checksum errors
-	FilesNew        uint64               // total number of files with hash|checksum errors
+	FilesNew        uint64               // total number of files not covered by the [previous] .hqMAP
+	FilesReused     uint64               // total number of unchanged files, hash reused from the previous .hqMAP [sign]
+	FilesChanged    uint64               // total number of files with a changed hash since the previous .hqMAP [sign]
+	FilesRemoved    uint64               // total number of files removed since the previous .hqMAP [sign]
+	FilesSkipped    uint64               // total number of files not hashed, size|mtime|mode unchanged [quick verify]
 	Signify         bool                 // enable optional OpenBSD signify signatures
 	PlainTextScript bool                 // Plain Text Posix script interp mode
-	MapClean        bool                 // true if we need to wipe old maps
 	Silent          bool                 // silent mode for benchmarking
+	JSON            bool                 // machine readable [NDJSON] reports, implies Silent for human output
 	UnlockedKey     bool                 // true if /.hq/.unlocked key was found
+	KeyPinned       bool                 // true if an explicit verify key is set [keystore bypass]
+	KeyStore        string               // keystore path override [default: ~/.hq/]
+	SignAs          string               // sign identity NameTAG [default: me]
+	Trust           Trust                // signer trust level [verify]
+	COSIG           []Cosignature        // additional co-signatures [multi-signature container]
+	Threshold       int                  // min number of distinct valid and trusted signers [verify]
+	Quick           bool                 // dir verify: only hash files with changed size|mtime|mode
+	Only            []string             // dir verify: only verify the selected entries [path|glob]
+	Signers         int                  // number of distinct valid and trusted signers [verify]
 	IsExec          bool                 // true if exec mode
 	ReportID        bool                 // Report Status [summary]
 	ReportTime      bool                 // Report Status [summary]
@@ -68,6 +86,7 @@ type IO struct {
 	SetMe           bool                 // Set Me Key Symbolic Link
 	PwdEnv          bool                 // true if pass creds from env
 	CPU             int                  // number of CPU cores
+	WalkThreads     int                  // parallel directory reads [0: number of CPU cores]
 	Start           time.Time            // Time Stamp Start Action
 	End             time.Time            // Time Stamp End Action
 	TSS             string               // POSIX TS (nanoseconds since 01/01/1970 00:00 UTC)
@@ -80,23 +99,144 @@ type IO struct {
 
 // Config ...
 type Config struct {
-	Action          string   // Requested Action [sign|verify|generate|bench]
-	Target          string   // Requested Target [dir|file]
-	TargetTS        string   // Requested Target TimeStamp
-	FileName        string   // FileName
-	File            *os.File // FileHandle
-	Signify         bool     // enable optional OpenBSD signify signatures
-	CodeReview      bool     // enable additional code-review hashes for source code files
-	Silent          bool     // enable silent mode [eg. for benchmarking]
-	IsExec          bool     // true if executeable mode is detected
-	IsPipe          bool     // true if exec mode is detected
-	MapOnly         bool     // true if exec mode is detected
-	RunExec         bool     // true if run mode [not display mode] is requested
-	PwdComplex      bool     // true if complex legacy password is requested
-	PlainTextScript bool     // run plaintext sh script interpreter
-	TokenExec       string   // magic token to determine exec type for execution
-	PwdService      string   // the [legacy] password service name [psn]
-	ScriptExtL      int      // lengh of extension name
+	Action          string    // Requested Action [sign|verify|generate|bench]
+	Target          string    // Requested Target [dir|file]
+	TargetTS        string    // Requested Target TimeStamp
+	FileName        string    // FileName
+	File            *os.File  // FileHandle
+	Stdout          io.Writer // human readable [or NDJSON] reports [nil: os.Stdout]
+	Stderr          io.Writer // prompts and diagnostics [nil: os.Stderr]
+	Signify         bool      // enable optional OpenBSD signify signatures
+	CodeReview      bool      // enable additional code-review hashes for source code files
+	Silent          bool      // enable silent mode [eg. for benchmarking]
+	JSON            bool      // machine readable [NDJSON] reports [--json]
+	Paranoid        bool      // dir sign: always rehash every file, ignore the stat cache [--paranoid]
+	Quick           bool      // dir verify: only hash files with changed size|mtime|mode [--quick]
+	Only            []string  // dir verify: only hash and report the selected entries [path|glob, --only]
+	Exclude         []string  // dir sign: additional ignore rules [--exclude]
+	Archive         bool      // sign|verify the members of the tar [gzip|zstd] or zip archive c.FileName [--archive]
+	MapDir          string    // dir sign|verify: .hqMAP [evidence] directory [--map-out|--map-in] [default: the signed directory]
+	Chain           bool      // dir verify: verify the hash chain of all .hqMAP snapshots [--chain]
+	KeepLast        int       // dir sign: keep the n most recent .hqMAPs [--keep-last]
+	KeepDaily       int       // dir sign: keep the most recent .hqMAP of the last n days [--keep-daily]
+	KeepWeekly      int       // dir sign: keep the most recent .hqMAP of the last n weeks [--keep-weekly]
+	Include         []string  // dir sign: re-include rules, override .hqignore [--include]
+	Follow          bool      // dir sign: follow symbolic links to directories [loop safe, --follow]
+	OneFS           bool      // dir sign: do not descend into other file systems [--one-fs]
+	MaxDepth        int       // dir sign: max depth of the signed entries [0: unlimited, --max-depth]
+	NoSpecial       bool      // dir sign: skip special files [pipe|socket|device, --no-special]
+	WalkThreads     int       // dir sign|verify: parallel directory reads [0: number of CPU cores, --walk-threads]
+	IsExec          bool      // true if executeable mode is detected
+	IsPipe          bool      // true if exec mode is detected
+	MapOnly         bool      // true if exec mode is detected
+	RunExec         bool      // true if run mode [not display mode] is requested
+	PwdComplex      bool      // true if complex legacy password is requested
+	PlainTextScript bool      // run plaintext sh script interpreter
+	TokenExec       string    // magic token to determine exec type for execution
+	PwdService      string    // the [legacy] password service name [psn]
+	Owner           string    // owner id for generate [non-interactive api use]
+	PassONE         string    // passphrase ONE [non-interactive api use]
+	PassTWO         string    // passphrase TWO [non-interactive api use]
+	KeyFile         string    // explicit public key file for verify [--key]
+	KeyRing         string    // keystore directory override [--keyring, default ~/.hq]
+	Key             *ID       // explicit public key for verify [api use, overrides KeyFile]
+	As              string    // sign with this identity NameTAG [--as, default: me]
+	Threshold       int       // min number of distinct valid and trusted signers [--threshold]
+	ScriptExtL      int       // lengh of extension name
+}
+
+// Cosignature is an additional signature over the same message hash [multi-signature container]
+type Cosignature struct {
+	TAG [30]byte            // signer NAME TAG
+	TSS string              // signature time stamp [unix seconds]
+	SIG [SignatureSize]byte // RAW SPHINCS-256 signature
+}
+
+// Signature reports the result of a sign operation
+type Signature struct {
+	ID                // signer identity
+	FileName   string // signed file [or .hqMAP]
+	TSS        string // signature time stamp [unix seconds]
+	Container  []byte // encoded .hqs|.hqx container
+	FilesTotal uint64 // number of files within the signed .hqMAP
+	// delta against the previous signed .hqMAP [dir sign]
+	FilesNew     uint64 // files not covered by the previous .hqMAP
+	FilesChanged uint64 // files with a changed hash
+	FilesRemoved uint64 // files removed
+	FilesReused  uint64 // unchanged files [size|mtime|inode|ctime], hash reused
+}
+
+// Result reports the result of a verify operation
+type Result struct {
+	ID                // signer identity
+	FileName   string // verified container
+	TSS        string // signature time stamp [unix seconds]
+	Valid      bool   // true if the signature is valid and the signer trusted
+	Trust      Trust  // signer trust level
+	Signers    int    // number of distinct valid and trusted signers
+	Script     []byte // decompressed .hqx payload [exec only]
+	FilesTotal uint64 // total number of files within the .hqMAP
+	FilesFail  uint64 // total number of files with hash|checksum errors
+	FilesNew   uint64 // total number of files not covered by the .hqMAP
+	// quick verify [files with unchanged size|mtime|mode are not hashed]
+	FilesSkipped uint64 // files confirmed by meta data only
+}
+
+// Diff reports the file level changes between two signed .hqMAP snapshots
+type Diff struct {
+	Dir      string   // signed directory
+	From     string   // older [first selected] .hqMAP
+	To       string   // newer [second selected] .hqMAP
+	FromTSS  string   // signature time stamp of From [unix seconds]
+	ToTSS    string   // signature time stamp of To [unix seconds]
+	Added    []string // files only in To
+	Removed  []string // files only in From
+	Modified []string // files with a changed hash or meta data
+	Renamed  []Rename // files with the same hash but a new path
+}
+
+// MapInfo reports a .hqMAP snapshot of a directory
+type MapInfo struct {
+	ID                // signer identity [empty if unknown]
+	FileName   string // .hqMAP
+	TSS        string // map time stamp [unix seconds]
+	FilesTotal uint64 // number of files within the .hqMAP
+	Valid      bool   // true if the signature is valid and the signer trusted
+	Trust      Trust  // signer trust level
+	Signers    int    // number of distinct valid and trusted signers
+	Err        error  // verify error [nil if valid]
+}
+
+// ChainLink reports the hash chain link of a .hqMAP snapshot to its predecessor
+type ChainLink struct {
+	MapInfo
+	Prev   string // previous .hqMAP [recorded at sign time, empty if none]
+	Status string // first|linked|pruned|gap|fork|replaced|invalid
+}
+
+// Chain reports the .hqMAP history of a directory, oldest first
+type Chain struct {
+	Dir     string
+	Links   []ChainLink
+	Gaps    int // missing previous maps [not removed by the retention policy]
+	Forks   int // maps not linked to their direct predecessor
+	Invalid int // invalid signatures, replaced maps
+}
+
+// WatchEvent reports a deviation of a watched directory from its signed .hqMAP
+type WatchEvent struct {
+	Time     time.Time
+	File     string // walk path
+	Reason   string // json reason [modified|removed|new|mode_changed|...], restored, watching [ready], error
+	Expected string // file hash [meta data] expected
+	Found    string // file hash [meta data] found
+	Error    string // rescan walk error [reason: error]
+}
+
+// Rename ...
+type Rename struct {
+	From string
+	To   string
 }
 
 //
@@ -105,17 +245,27 @@ type Config struct {
 
 // NewHQ ...
 func NewHQ(c *Config) *HQ {
+	u := c.ui()
 	return &HQ{
-		ID{},
-		IO{
+		ID: ID{},
+		IO: IO{
 			Start:       time.Now(),
-			ColorUI:     getColorUI(),
+			ColorUI:     u.color,
 			ReportID:    true,
 			ReportValid: false,
 			ReportTime:  true,
 			CPU:         runtime.NumCPU(),
 			Signify:     c.Signify,
+			Silent:      c.Silent || c.JSON,
+			JSON:        c.JSON,
+			KeyStore:    keyRing(c.KeyRing),
+			SignAs:      c.As,
+			Threshold:   c.Threshold,
+			Quick:       c.Quick,
+			Only:        c.Only,
+			WalkThreads: c.WalkThreads,
 		},
+		ui: u,
 	}
 }
 
@@ -128,6 +278,9 @@ func NewConfig() *Config {
 		Target:     "file",
 		PwdComplex: true,
 		Signify:    isEnv(_envHQSignify),
+		KeepLast:   _mapKeepLast,
+		KeepDaily:  _mapKeepDaily,
+		KeepWeekly: _mapKeepWeekly,
 	}
 }
 
@@ -135,174 +288,281 @@ func NewConfig() *Config {
 // EXPORTED FUNCTIONS
 //
 
+// Generate creates [or re-produces] the hq identity for c.PassONE, c.PassTWO
+// and env HQ_OWNER, stores the public key and sets the me link
+func Generate(ctx context.Context, c *Config) (*ID, error) {
+	id, err := c.generate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &id.ID, nil
+}
+
+// Sign signs c.FileName [.hqs] or, if c.IsExec is set, the executable c.FileName [.hqx]
+func Sign(ctx context.Context, c *Config) (*Signature, error) {
+	id, err := c.fileSign(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return id.signature()
+}
+
+// Verify verifies the .hqs|.hqx container c.FileName, the returned Result is
+// valid for inspection even if the error is ErrSignatureMismatch
+func Verify(ctx context.Context, c *Config) (*Result, error) {
+	id, err := c.fileVerify(ctx)
+	if id == nil {
+		return nil, err
+	}
+	return id.result(), err
+}
+
+// SignReader signs the message read from reader and returns the .hqs container
+// [Signature.Container] without writing to the filesystem
+func SignReader(ctx context.Context, c *Config, reader io.Reader) (*Signature, error) {
+	id, err := c.readerSign(ctx, reader)
+	if err != nil {
+		return nil, err
+	}
+	return id.signature()
+}
+
+// SignBytes signs msg, see SignReader
+func SignBytes(ctx context.Context, c *Config, msg []byte) (*Signature, error) {
+	return SignReader(ctx, c, bytes.NewReader(msg))
+}
+
+// VerifyReader verifies the .hqs container sig against the message read from reader,
+// the reader is not consumed for self-contained .hqx containers [may be nil]
+func VerifyReader(ctx context.Context, c *Config, reader io.Reader, sig []byte) (*Result, error) {
+	id, err := c.readerVerify(ctx, reader, sig)
+	if id == nil {
+		return nil, err
+	}
+	return id.result(), err
+}
+
+// VerifyBytes verifies the .hqs container sig against msg, see VerifyReader
+func VerifyBytes(ctx context.Context, c *Config, msg, sig []byte) (*Result, error) {
+	return VerifyReader(ctx, c, bytes.NewReader(msg), sig)
+}
+
+// SignDir writes and signs a new .hqMAP for the directory c.FileName
+func SignDir(ctx context.Context, c *Config) (*Signature, error) {
+	id, err := c.dirSign(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if c.MapOnly {
+		return &Signature{
+			FileName:     id.IO.FileName,
+			FilesTotal:   id.IO.FilesTotal,
+			FilesNew:     id.IO.FilesNew,
+			FilesChanged: id.IO.FilesChanged,
+			FilesRemoved: id.IO.FilesRemoved,
+			FilesReused:  id.IO.FilesReused,
+		}, nil
+	}
+	return id.signature()
+}
+
+// VerifyDir verifies the directory c.FileName against its [c.TargetTS selected] .hqMAP,
+// the returned Result is valid for inspection even if the error is
+// ErrSignatureMismatch or ErrFilesModified
+func VerifyDir(ctx context.Context, c *Config) (*Result, error) {
+	id, err := c.dirVerify(ctx)
+	if id == nil {
+		return nil, err
+	}
+	return id.result(), err
+}
+
+// SignArchive writes and signs the .hqMAP of all members of the tar [gzip|zstd] or zip
+// archive c.FileName, without extraction
+func SignArchive(ctx context.Context, c *Config) (*Signature, error) {
+	id, err := c.archiveSign(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if c.MapOnly {
+		return &Signature{FileName: id.IO.FileName, FilesTotal: id.IO.FilesTotal}, nil
+	}
+	return id.signature()
+}
+
+// VerifyArchive verifies all members of the archive c.FileName against its signed .hqMAP,
+// the returned Result is valid for inspection even if the error is
+// ErrSignatureMismatch or ErrFilesModified
+func VerifyArchive(ctx context.Context, c *Config) (*Result, error) {
+	id, err := c.archiveVerify(ctx)
+	if id == nil {
+		return nil, err
+	}
+	return id.result(), err
+}
+
+// VerifyPaths verifies the selected paths [relative to c.FileName, .hqignore glob syntax,
+// a directory selects its content] against the [c.TargetTS selected] .hqMAP of the
+// directory c.FileName, the .hqMAP signature is always verified
+func VerifyPaths(ctx context.Context, c *Config, paths []string) (*Result, error) {
+	sel := *c
+	sel.Only = append(slices.Clone(c.Only), paths...)
+	return VerifyDir(ctx, &sel)
+}
+
+// Watch verifies the signed .hqMAP of the directory c.FileName, then reports every
+// deviation [and its restore] to fn till ctx is done [linux: inotify]
+func Watch(ctx context.Context, c *Config, fn func(WatchEvent)) error {
+	return c.watch(ctx, fn)
+}
+
+// Maps lists and verifies all .hqMAP snapshots of the directory c.FileName, oldest first
+func Maps(ctx context.Context, c *Config) ([]MapInfo, error) {
+	return c.maps(ctx)
+}
+
+// VerifyChain verifies the hash chain of all .hqMAP snapshots of the directory c.FileName,
+// returns ErrChainBroken [and the chain report] on gaps, forks or replaced snapshots
+func VerifyChain(ctx context.Context, c *Config) (*Chain, error) {
+	return c.chain(ctx)
+}
+
+// DiffDir compares the signed .hqMAP snapshots of the directory c.FileName selected by
+// the time stamp prefixes ts1 and ts2, both signatures are verified first
+func DiffDir(ctx context.Context, c *Config, ts1, ts2 string) (*Diff, error) {
+	return c.diff(ctx, ts1, ts2)
+}
+
+// Cosign appends a co-signature of the [c.As] identity to the .hqs container c.FileName
+// [or the current .hqMAP of the directory c.FileName], the existing signature must validate
+func Cosign(ctx context.Context, c *Config) (*Signature, error) {
+	id, err := c.cosign(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return id.signature()
+}
+
+// ExportKey returns the armored public key block for nametag [default: me]
+func ExportKey(c *Config, nametag string) ([]byte, error) {
+	return c.exportKey(nametag)
+}
+
+// ImportKey verifies the armored public key block [NameTAG checksum] and adds it to the keystore
+func ImportKey(c *Config, armor []byte) (*ID, error) {
+	id, err := c.importKey(armor)
+	if err != nil {
+		return nil, err
+	}
+	return &id.ID, nil
+}
+
+// Revoke issues [or returns the already existing] signed revocation certificate for
+// the me identity, signatures made at or after the revocation time are rejected
+func Revoke(ctx context.Context, c *Config, reason string) ([]byte, error) {
+	_, cert, err := c.revoke(ctx, reason)
+	return cert, err
+}
+
+// ImportRevocation validates the revocation certificate against the keystore
+// public key and stores it next to the key
+func ImportRevocation(c *Config, cert []byte) (*ID, error) {
+	id, err := c.importRevocation(cert)
+	if err != nil {
+		return nil, err
+	}
+	return &id.ID, nil
+}
+
+// Identities returns all public keys [hq identities] within the keystore, unreadable or
+// corrupt key files are skipped, their errors are returned [joined] with all readable identities
+func Identities(c *Config) ([]ID, error) {
+	ids, skipped, err := c.identities()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]ID, 0, len(ids))
+	for _, id := range ids {
+		list = append(list, id.ID)
+	}
+	return list, errors.Join(skipped...)
+}
+
+// UseIdentity sets nametag as default [me] sign identity
+func UseIdentity(c *Config, nametag string) (*ID, error) {
+	id, err := c.useID(nametag)
+	if err != nil {
+		return nil, err
+	}
+	return &id.ID, nil
+}
+
+// RemoveIdentity deletes nametag [public, unlocked key, revocation, trust entry] from the keystore
+func RemoveIdentity(c *Config, nametag string) error { return c.removeID(nametag) }
+
 // ParseCmd ...
 func (c *Config) ParseCmd() { c.parseCmd() }
 
+// Run executes the [ParseCmd] requested action
+func (c *Config) Run(ctx context.Context) error { return c.run(ctx) }
+
 // RunAction ...
-func (c *Config) RunAction() bool { return c.runAction() }
+func (c *Config) RunAction() bool { return cliReport(c.run(context.Background())) }
 
 // DirVerify ...
-func (c *Config) DirVerify() bool { return c.dirVerify() }
+func (c *Config) DirVerify() bool {
+	_, err := c.dirVerify(context.Background())
+	return cliReport(err)
+}
 
 // DirSign ...
-func (c *Config) DirSign() bool { return c.dirSign() }
+func (c *Config) DirSign() bool {
+	_, err := c.dirSign(context.Background())
+	return cliReport(err)
+}
 
 // CryptoVerify ...
 func (c *Config) CryptoVerify() bool { return c.cryptoVerify() }
 
 // RunExecPlain ...
-func (c *Config) RunExecPlain() bool { return c.runExecPlain() }
+func (c *Config) RunExecPlain() bool { return cliReport(c.runExecPlain()) }
 
 // Bench ...
 func (c *Config) Bench() bool { return c.bench() }
 
 // Generate sphincs keypair
 func (c *Config) Generate() bool {
-	id := NewHQ(c)
-	id.IO.SetMe = true
-	id.ID.OWNER = getOwner()
-	id.passEntry("create hq identity")
-	id.IO.Start = time.Now()
-	id.genSphincs()
-	id.writePublicKey()
-	id.report()
-	return true
+	_, err := c.generate(context.Background())
+	return cliReport(err)
 }
 
 // FileSign ...
 func (c *Config) FileSign() bool {
-	id := NewHQ(c)
-	id.IO.TSS = strconv.FormatInt(id.IO.Start.Unix(), 10)
-	id.IO.FileName = c.FileName
-	id.IO.ScriptExtL = c.ScriptExtL
-	chanHash := make(chan [HashSize]byte, 1)
-	go func() {
-		chanHash <- getMSGHash(id.IO.FileName)
-		close(chanHash)
-	}()
-	id.readPublicKey(_me)
-	id.passEntry("pending " + c.Target + " sign operation [" + id.IO.FileName + "]")
-	id.IO.MSG = <-chanHash
-	id.IO.Start = time.Now()
-	id.unlockHQ()
-	id.genSig()
-	id.writeSig()
-	id.report()
-	return true
+	_, err := c.fileSign(context.Background())
+	return cliReport(err)
 }
 
 // FileSignExecuteable ...
 func (c *Config) FileSignExecuteable() bool {
-	id := NewHQ(c)
-	id.IO.TSS = strconv.FormatInt(id.IO.Start.Unix(), 10)
-	id.IO.FileName = c.FileName
-	id.IO.ScriptExtL = c.ScriptExtL
-	id.IO.IsExec = true
-	id.IO.TokenExec = c.TokenExec
-	id.IO.SCRIPT = compressZstd(readFileErrExit(id.IO.FileName), _compressedScriptLevel)
-	id.readPublicKey(_me)
-	id.passEntry("pending " + c.Target + " sign operation [" + id.IO.FileName + "]")
-	id.IO.Start = time.Now()
-	id.unlockHQ()
-	id.genSig()
-	id.writeSig()
-	id.report()
-	return true
+	c.IsExec = true
+	_, err := c.fileSign(context.Background())
+	return cliReport(err)
 }
 
 // FileVerify ...
 func (c *Config) FileVerify() bool {
-	id := NewHQ(c)
-	id.IO.FileName = c.FileName
-	id.IO.ReportValid = false
-	id.parseSig(c)
-	if id.validateSig() {
-		id.IO.ReportValid = true
-		id.report()
-		return true
-	}
-	id.report()
-	if id.IO.ColorUI {
-		stat, fail = _Stat, _Fail
-	}
-	out(stat + "SIGNATURE VALIDATION: " + fail)
-	return false
+	_, err := c.fileVerify(context.Background())
+	return cliReport(err)
 }
 
 // FileVerifyExecuteable verifies and executes an hq singed hqx container
-func (c *Config) FileVerifyExecuteable() bool {
-	id := NewHQ(c)
-	id.IO.FileName = c.FileName
-	id.IO.IsExec = c.IsExec
-	id.IO.ReportValid = false
-	id.parseSig(c)
-	if id.validateSig() {
-		id.IO.ReportValid = true
-		id.report()
-		if c.RunExec {
-			return id.runExec()
-		}
-		out(string(decompressZstd(id.IO.SCRIPT)))
-		return true
-	}
-	id.report()
-	if id.IO.ColorUI {
-		stat, fail = _Stat, _Fail
-	}
-	out(stat + "SIGNATURE VALIDATION: " + fail)
-	return false
-}
+func (c *Config) FileVerifyExecuteable() bool { return cliReport(c.execVerify(context.Background())) }
 
 // Unlock unlocks the raw sphincs key for subsequent batch operations
-func (c *Config) Unlock() bool {
-	if !_allowUnlockViaEnv {
-		errExit("store unlocked key operations disabled by security policy")
-	}
-	id := NewHQ(c)
-	id.readPublicKey(_me)
-	id.passEntry("pending unlock operation")
-	switch {
-	case id.validateKey():
-		id.report()
-		return true
-	case !id.wipeUnlockedKey():
-		errExit("unable to [write|delete] key to ~/hq/.unlocked")
-	}
-	id.IO.Start = time.Now()
-	id.unlockHQ()
-	id.genSig()
-	id.writeUnlockedKey()
-	id.report()
-	return true
-}
+func (c *Config) Unlock() bool { return cliReport(c.unlock(context.Background())) }
 
 // Lock cleans the Unlock() exposed raw key
-func (c *Config) Lock() bool {
-	if !_allowUnlockViaEnv {
-		errExit("store unlocked key operations disabled by security policy")
-	}
-	id := NewHQ(c)
-	id.readPublicKey(_me)
-	id.report()
-	id.IO.Start = time.Now()
-	return id.wipeUnlockedKey()
-}
+func (c *Config) Lock() bool { return cliReport(c.lock(context.Background())) }
 
 // LegacyPass is a [k]ey[d]erivation[f]unction for legacy passwords
-func (c *Config) LegacyPass() bool {
-	id := NewHQ(c)
-	id.pwdTarget(c)
-	id.readPublicKey(_me)
-	id.passEntry("legacy password [generation|reproduction]")
-	id.IO.Start = time.Now()
-	id.unlockHQ()
-	id.genSig()
-	id.report()
-	id.IO.Start = time.Now()
-	id.reportPwd(c)
-	id.IO.End = time.Time{}
-	id.IO.ReportID = false
-	id.IO.ReportTime = true
-	id.report()
-	return true
-}
+func (c *Config) LegacyPass() bool { return cliReport(c.legacyPass(context.Background())) }
@@ -0,0 +1,237 @@
+package hq
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testConfig generates a test identity within a temporary keystore
+func testConfig(t testing.TB) *Config {
+	t.Helper()
+	c := NewConfig()
+	c.IsPipe, c.MapOnly = false, false
+	c.KeyRing = t.TempDir()
+	c.Owner = "tester@hq.test"
+	c.PassONE, c.PassTWO = "passphrase-one", "passphrase-two"
+	c.Stdout, c.Stderr = io.Discard, io.Discard
+	if _, err := Generate(context.Background(), c); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// testFile writes content to a temporary file
+func testFile(t testing.TB, content string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "msg.txt")
+	if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestVerifyConcurrent(t *testing.T) {
+	ctx := context.Background()
+	c := testConfig(t)
+	c.FileName = testFile(t, "concurrent verify")
+	if _, err := Sign(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	reports := make([]bytes.Buffer, 2)
+	for i := range reports {
+		v := *c
+		v.FileName += _extSignature
+		v.Stdout = &reports[i]
+		wg.Go(func() {
+			r, err := Verify(ctx, &v)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !r.Valid {
+				t.Error("signature not valid")
+			}
+		})
+	}
+	wg.Wait()
+	for i := range reports {
+		if !strings.Contains(reports[i].String(), _valid) {
+			t.Errorf("report %d: missing %s [%q]", i, _valid, reports[i].String())
+		}
+	}
+}
+
+// endlessReader counts its reads, it never reaches EOF
+type endlessReader struct{ reads atomic.Int64 }
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	r.reads.Add(1)
+	time.Sleep(time.Millisecond)
+	return len(p), nil
+}
+
+func TestSignReaderReleasesReader(t *testing.T) {
+	c := NewConfig()
+	c.KeyRing = t.TempDir() // no identity, sign fails early
+	c.PassONE, c.PassTWO = "passphrase-one", "passphrase-two"
+	c.Stdout, c.Stderr = io.Discard, io.Discard
+	r := &endlessReader{}
+	if _, err := SignReader(context.Background(), c, r); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+	n := r.reads.Load()
+	time.Sleep(50 * time.Millisecond)
+	if m := r.reads.Load(); m != n {
+		t.Fatalf("reader still in use after SignReader returned [%d -> %d reads]", n, m)
+	}
+}
+
+func TestIdentitiesSkipsCorruptKey(t *testing.T) {
+	c := testConfig(t)
+	corrupt := filepath.Join(c.KeyRing, "AAAAAA-AA-AAAAAA-AA-AAAAAAAAAA")
+	if err := os.WriteFile(corrupt, []byte("corrupt"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := Identities(c)
+	if !errors.Is(err, ErrCorruptKey) {
+		t.Fatalf("expected ErrCorruptKey for the corrupt key file, got %v", err)
+	}
+	if len(ids) != 1 || unpad(ids[0].OWNER) != c.Owner {
+		t.Fatalf("expected the generated identity, got %d identities", len(ids))
+	}
+}
+
+func TestVerifyPinnedKeyThreshold(t *testing.T) {
+	ctx := context.Background()
+	c := testConfig(t)
+	c.FileName = testFile(t, "pinned threshold")
+	if _, err := Sign(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := Identities(c)
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("identities: %v", err)
+	}
+	v := *c
+	v.FileName += _extSignature
+	v.Key = &ids[0]
+	if _, err := Verify(ctx, &v); err != nil {
+		t.Fatalf("pinned key: %v", err)
+	}
+	v.Threshold = 2
+	if _, err := Verify(ctx, &v); !errors.Is(err, ErrThreshold) {
+		t.Fatalf("pinned key with threshold 2: expected ErrThreshold, got %v", err)
+	}
+}
+
+func TestVerifyReaderNilReader(t *testing.T) {
+	ctx := context.Background()
+	c := testConfig(t)
+	c.FileName = testFile(t, "nil reader")
+	s, err := Sign(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyReader(ctx, c, nil, s.Container); err == nil {
+		t.Fatal(".hqs container verified without a message reader")
+	}
+	if r, err := VerifyReader(ctx, c, strings.NewReader("nil reader"), s.Container); err != nil || !r.Valid {
+		t.Fatalf("verify: %v", err)
+	}
+}
+
+func TestVerifyKeyFileWithoutKeystore(t *testing.T) {
+	ctx := context.Background()
+	c := testConfig(t)
+	c.FileName = testFile(t, "hermetic verify")
+	if _, err := Sign(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	keyFile := func(c *Config) string {
+		armor, err := ExportKey(c, _me)
+		if err != nil {
+			t.Fatal(err)
+		}
+		name := filepath.Join(t.TempDir(), "key.pub")
+		if err := os.WriteFile(name, armor, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return name
+	}
+	v := *c
+	v.FileName += _extSignature
+	v.KeyRing = filepath.Join(t.TempDir(), "missing")
+	if _, err := Verify(ctx, &v); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("empty keyring: expected ErrKeyNotFound, got %v", err)
+	}
+	v.KeyFile = keyFile(c)
+	if r, err := Verify(ctx, &v); err != nil || !r.Valid {
+		t.Fatalf("key file: %v", err)
+	}
+	other := *c
+	other.Owner = "other@hq.test" // identities derive from owner and passphrases
+	if _, err := Generate(ctx, &other); err != nil {
+		t.Fatal(err)
+	}
+	v.KeyFile = keyFile(&other)
+	if _, err := Verify(ctx, &v); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("key file of another identity: expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestIdentitiesUseSignAsRemove(t *testing.T) {
+	ctx := context.Background()
+	c := testConfig(t)
+	first, err := UseIdentity(c, _me)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := *c
+	other.Owner = "other@hq.test"
+	second, err := Generate(ctx, &other) // new me
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids, err := Identities(c); err != nil || len(ids) != 2 {
+		t.Fatalf("identities: %d %v", len(ids), err)
+	}
+	if _, err := UseIdentity(c, string(first.TAG[:])); err != nil {
+		t.Fatal(err)
+	}
+	c.FileName = testFile(t, "sign as")
+	for _, tc := range []struct {
+		as   string
+		want *ID
+	}{
+		{"", first},
+		{string(second.TAG[:]), second},
+	} {
+		c.As = tc.as
+		s, err := Sign(ctx, c)
+		if err != nil {
+			t.Fatalf("sign as %q: %v", tc.as, err)
+		}
+		if s.TAG != tc.want.TAG {
+			t.Fatalf("sign as %q: signed by %s", tc.as, s.TAG[:])
+		}
+	}
+	if err := RemoveIdentity(c, string(second.TAG[:])); err != nil {
+		t.Fatal(err)
+	}
+	if ids, err := Identities(c); err != nil || len(ids) != 1 || ids[0].TAG != first.TAG {
+		t.Fatalf("identities after remove: %d %v", len(ids), err)
+	}
+	if _, err := Sign(ctx, c); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("sign as removed identity: expected ErrKeyNotFound, got %v", err)
+	}
+}
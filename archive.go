@@ -0,0 +1,373 @@
+package hq
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// const
+const (
+	_archiveMapExt = ".hqMAP" + _compressedFileExt
+	_tmpExt        = ".tmp" // unsigned map in progress
+)
+
+// archiveEntry ...
+type archiveEntry struct {
+	name string
+	hash string
+	meta fileMeta
+}
+
+// archiveMap returns the .hqMAP of the archive c.FileName
+func (c *Config) archiveMap() string {
+	if c.MapDir != "" {
+		return fixPath(c.MapDir) + filepath.Base(c.FileName) + _archiveMapExt
+	}
+	return c.FileName + _archiveMapExt
+}
+
+// memberName ...
+func memberName(name string) string {
+	return path.Clean(strings.TrimLeft(name, "/"))
+}
+
+// hashMember ...
+func hashMember(r io.Reader) (string, error) {
+	h := blake3New256()
+	if err := hashBlocks(h, r); err != nil {
+		return "", err
+	}
+	return string(s2hex(h.Sum(nil))), nil
+}
+
+// walkArchive streams the files and links of the tar [plain|gzip|zstd] or zip archive filename
+// into fn, in archive order, duplicate member names are rejected
+func walkArchive(ctx context.Context, filename string, fn func(e archiveEntry) error) error {
+	names := make(map[string]bool)
+	member := func(e archiveEntry) error {
+		if names[e.name] {
+			return fmt.Errorf("duplicate archive member [%s] [%s]", filename, e.name)
+		}
+		names[e.name] = true
+		return fn(e)
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("unable to read archive [%s] [%w]", filename, err)
+	}
+	defer f.Close()
+	magic := make([]byte, 4)
+	n, _ := io.ReadFull(f, magic)
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("unable to read archive [%s] [%w]", filename, err)
+	}
+	var r io.Reader = f
+	switch magic = magic[:n]; {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")), bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		fi, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("unable to read archive [%s] [%w]", filename, err)
+		}
+		zr, err := zip.NewReader(f, fi.Size())
+		if err != nil {
+			return fmt.Errorf("unable to read zip archive [%s] [%w]", filename, err)
+		}
+		return walkZip(ctx, filename, zr, member)
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("unable to read gzip archive [%s] [%w]", filename, err)
+		}
+		defer gz.Close()
+		r = gz
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		d, err := zstd.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("unable to read zstd archive [%s] [%w]", filename, err)
+		}
+		defer d.Close()
+		r = d
+	}
+	return walkTar(ctx, filename, tar.NewReader(r), member)
+}
+
+// walkTar ...
+func walkTar(ctx context.Context, filename string, tr *tar.Reader, fn func(e archiveEntry) error) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read tar archive [%s] [%w]", filename, err)
+		}
+		e := archiveEntry{
+			name: memberName(hdr.Name),
+			meta: fileMeta{
+				size:     hdr.Size,
+				mtime:    hdr.ModTime.UnixNano(),
+				mode:     uint32(hdr.Mode) & 0o7777,
+				uid:      uint32(hdr.Uid),
+				gid:      uint32(hdr.Gid),
+				hasStat:  true,
+				hasOwner: true,
+			},
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			e.meta.ftype = "file"
+		case tar.TypeSymlink:
+			e.meta.ftype, e.meta.link = "symlink", hdr.Linkname
+		case tar.TypeLink:
+			e.meta.ftype, e.meta.link = "hardlink", memberName(hdr.Linkname)
+		default:
+			continue // directories, special files
+		}
+		if e.hash, err = hashMember(tr); err != nil {
+			return fmt.Errorf("unable to read tar archive [%s] [%s] [%w]", filename, e.name, err)
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+}
+
+// walkZip ...
+func walkZip(ctx context.Context, filename string, zr *zip.Reader, fn func(e archiveEntry) error) error {
+	for _, zf := range zr.File {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		mode := zf.Mode()
+		if !mode.IsRegular() && mode&os.ModeSymlink == 0 {
+			continue // directories, special files
+		}
+		e := archiveEntry{
+			name: memberName(zf.Name),
+			meta: fileMeta{
+				ftype:   fileType(mode),
+				size:    int64(zf.UncompressedSize64),
+				mtime:   zf.Modified.UnixNano(),
+				mode:    unixMode(mode),
+				hasStat: true,
+			},
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return fmt.Errorf("unable to read zip archive [%s] [%s] [%w]", filename, e.name, err)
+		}
+		var data []byte
+		r := io.Reader(rc)
+		if mode&os.ModeSymlink != 0 {
+			data, err = io.ReadAll(rc) // link target
+			e.meta.link, r = string(data), bytes.NewReader(nil)
+		}
+		if err == nil {
+			e.hash, err = hashMember(r)
+		}
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("unable to read zip archive [%s] [%s] [%w]", filename, e.name, err)
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archiveSign writes and signs the .hqMAP of all members of the archive c.FileName [no extraction]
+func (c *Config) archiveSign(ctx context.Context) (*HQ, error) {
+	id := NewHQ(c)
+	id.setPass(c)
+	id.IO.DirName = c.FileName
+	id.IO.TSS = strconv.FormatInt(id.IO.Start.Unix(), 10)
+	id.IO.FileName = c.archiveMap()
+
+	// the map is written to tmp, it replaces the previous map once signed
+	tmp := id.IO.FileName + _tmpExt
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	mapOut, err := createCompressFile(tmp, _compressedMapLevel, 0o660)
+	if err != nil {
+		return nil, err
+	}
+
+	// stream members into the compressed map
+	type done struct {
+		total uint64
+		end   time.Time
+		err   error
+	}
+	chanDone := make(chan done, 1)
+	go func() {
+		var (
+			total  uint64
+			record []byte
+		)
+		_, err := mapOut.Write(newMap(filepath.Base(c.FileName), walkOptions{}, mapLink{}))
+		if err == nil {
+			err = walkArchive(ctx, c.FileName, func(e archiveEntry) error {
+				total++
+				record = appendMapRecord(record[:0], e.name, e.hash, "", e.meta.encode())
+				_, err := mapOut.Write(record)
+				return err
+			})
+		}
+		if err == nil {
+			err = mapOut.Close()
+		}
+		if err != nil {
+			mapOut.abort()
+		}
+		chanDone <- done{total: total, end: time.Now(), err: err}
+		close(chanDone)
+	}()
+
+	// discard stops the archive walk and removes tmp, the previous map stays in place
+	discard := func(err error) error {
+		cancel()
+		<-chanDone
+		os.Remove(tmp)
+		return err
+	}
+
+	// prep sign
+	if !c.MapOnly {
+		id.IO.ReportValid = false
+		if err := id.prepSign("pending hqMAP sign operation [" + c.FileName + "]"); err != nil {
+			return nil, discard(err)
+		}
+	}
+
+	// wait, report, sign
+	r := <-chanDone
+	if r.err != nil {
+		return nil, fmt.Errorf("unable to write .hqMAP [%s] [%w]", id.IO.FileName, r.err)
+	}
+	id.IO.FilesTotal = r.total
+	id.IO.ReportValid = false
+	id.IO.End = r.end
+	id.reportDir()
+	if !c.MapOnly {
+		if id.IO.MSG, err = getMSGHash(tmp); err != nil {
+			return nil, discard(err)
+		}
+		id.IO.End = time.Time{}
+		id.IO.Start = time.Now()
+		if err = id.genSig(); err != nil {
+			return nil, discard(err)
+		}
+		id.report()
+		if err = id.writeSig(); err != nil {
+			return nil, discard(err)
+		}
+	}
+	if err = os.Rename(tmp, id.IO.FileName); err != nil {
+		return nil, discard(fmt.Errorf("unable to write .hqMAP [%s] [%w]", id.IO.FileName, err))
+	}
+	return id, nil
+}
+
+// archiveVerify verifies all members of the archive c.FileName against its signed .hqMAP
+func (c *Config) archiveVerify(ctx context.Context) (*HQ, error) {
+	id := NewHQ(c)
+	id.IO.DirName = c.FileName
+	id.IO.ReportValid = true
+	if err := id.pinKey(c); err != nil {
+		return nil, err
+	}
+	mapName := c.archiveMap()
+	id.IO.FileName = mapName + _extSignature
+	data, err := decompressReadFile(mapName)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := parseMap(data, ".")
+	if err != nil {
+		return nil, err
+	}
+	if err = id.parseSig(c); err != nil {
+		return nil, err
+	}
+	if id.IO.IsExec {
+		return nil, fmt.Errorf("%w [%s]", ErrCorruptContainer, id.IO.FileName)
+	}
+	report := func(msg string) {
+		if !id.IO.Silent || id.IO.JSON {
+			id.ui.out(msg)
+		}
+	}
+	fail := func(f failed) {
+		if !f.more {
+			id.IO.FilesFail++
+		}
+		report(id.reportFail(f))
+	}
+	seen := make(map[string]bool, len(signed))
+	err = walkArchive(ctx, c.FileName, func(e archiveEntry) error {
+		x, ok := signed[e.name]
+		if !ok {
+			id.IO.FilesNew++
+			if id.IO.JSON {
+				report(jsonLine(jsonFile{Type: "file", File: e.name, Reason: _jsonNew}))
+				return nil
+			}
+			report(id.ui.fnew + e.name + id.ui.cOFF)
+			return nil
+		}
+		seen[e.name] = true
+		var reported bool
+		if x.hasMeta {
+			for _, d := range x.meta.drift(e.meta) {
+				fail(failed{filename: e.name, reason: d.reason, exp: d.exp, calc: d.found, more: reported})
+				reported = true
+			}
+		}
+		if x.hash != e.hash {
+			fail(failed{filename: e.name, reason: _reasonModified, exp: x.hash, calc: e.hash, more: reported})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for name := range signed {
+		if !seen[name] {
+			removed = append(removed, name)
+		}
+	}
+	slices.Sort(removed)
+	for _, name := range removed {
+		fail(failed{filename: name, reason: _reasonRemoved})
+	}
+	id.IO.FilesTotal = uint64(len(signed))
+	id.IO.ReportValid = false
+	id.reportDir()
+	id.IO.Start = time.Now()
+	if err = id.verifySigs(c.FileName); err != nil {
+		return id, err
+	}
+	if id.IO.FilesFail != 0 {
+		return id, fmt.Errorf("%w [%d files]", ErrFilesModified, id.IO.FilesFail)
+	}
+	return id, nil
+}
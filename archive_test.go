@@ -0,0 +1,138 @@
+package hq
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// testArchive writes the archive members [name: content] in order, the format follows
+// the name extension [.zip, .tar.gz, .tar.zst, .tar]
+func testArchive(t testing.TB, name string, members [][2]string) string {
+	t.Helper()
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	mtime := time.Unix(1700000000, 0)
+	if strings.HasSuffix(name, ".zip") {
+		zw := zip.NewWriter(f)
+		for _, m := range members {
+			w, err := zw.CreateHeader(&zip.FileHeader{Name: m[0], Method: zip.Deflate, Modified: mtime})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte(m[1])); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return name
+	}
+	var w io.WriteCloser = nopCloser{f}
+	switch {
+	case strings.HasSuffix(name, ".gz"):
+		w = gzip.NewWriter(f)
+	case strings.HasSuffix(name, ".zst"):
+		if w, err = zstd.NewWriter(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw := tar.NewWriter(w)
+	for _, m := range members {
+		hdr := &tar.Header{Name: m[0], Mode: 0o644, Size: int64(len(m[1])), ModTime: mtime, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(m[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+// nopCloser ...
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+func TestArchiveFormats(t *testing.T) {
+	ctx := context.Background()
+	c := testConfig(t)
+	for _, ext := range []string{".tar", ".tar.gz", ".tar.zst", ".zip"} {
+		c.FileName = testArchive(t, filepath.Join(t.TempDir(), "a"+ext), [][2]string{{"a", "1"}, {"b/c", "2"}, {"b/d", "3"}})
+		s, err := SignArchive(ctx, c)
+		if err != nil {
+			t.Fatalf("%s: sign: %v", ext, err)
+		}
+		if s.FilesTotal != 3 {
+			t.Fatalf("%s: signed %d members", ext, s.FilesTotal)
+		}
+		if r, err := VerifyArchive(ctx, c); err != nil || !r.Valid || r.FilesTotal != 3 {
+			t.Fatalf("%s: verify: %v", ext, err)
+		}
+		testArchive(t, c.FileName, [][2]string{{"a", "1"}, {"b/c", "changed"}, {"b/e", "4"}})
+		r, err := VerifyArchive(ctx, c)
+		if !errors.Is(err, ErrFilesModified) || r.FilesFail != 2 || r.FilesNew != 1 {
+			t.Fatalf("%s: modified archive: %v [%+v]", ext, err, r)
+		}
+	}
+}
+
+func TestSignArchiveBadPassphraseKeepsSignedMap(t *testing.T) {
+	ctx := context.Background()
+	c := testConfig(t)
+	c.FileName = testArchive(t, filepath.Join(t.TempDir(), "a.tar"), [][2]string{{"a", "1"}, {"b/c", "2"}})
+	if _, err := SignArchive(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	testArchive(t, c.FileName, [][2]string{{"a", "1"}, {"b/c", "changed"}})
+	bad := *c
+	bad.PassONE = "wrong-passphrase"
+	if _, err := SignArchive(ctx, &bad); err == nil {
+		t.Fatal("sign with a wrong passphrase succeeded")
+	}
+	if _, err := os.Stat(c.archiveMap() + _tmpExt); !os.IsNotExist(err) {
+		t.Fatalf("unsigned map left behind [%v]", err)
+	}
+	r, err := VerifyArchive(ctx, c)
+	if !errors.Is(err, ErrFilesModified) || r == nil || r.FilesFail != 1 {
+		t.Fatalf("previous map no longer verifies: %v", err)
+	}
+}
+
+func TestArchiveDuplicateMembers(t *testing.T) {
+	ctx := context.Background()
+	c := testConfig(t)
+	c.FileName = testArchive(t, filepath.Join(t.TempDir(), "a.tar"), [][2]string{{"a", "1"}, {"./a", "2"}})
+	if _, err := SignArchive(ctx, c); err == nil {
+		t.Fatal("signed an archive with duplicate members")
+	}
+	testArchive(t, c.FileName, [][2]string{{"a", "1"}})
+	if _, err := SignArchive(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	testArchive(t, c.FileName, [][2]string{{"a", "1"}, {"a", "evil"}})
+	if r, err := VerifyArchive(ctx, c); err == nil || errors.Is(err, ErrFilesModified) || r != nil {
+		t.Fatalf("expected a duplicate member error, got %v", err)
+	}
+}
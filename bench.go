@@ -18,28 +18,32 @@ const (
 func (c *Config) bench() bool {
 	t0 := time.Now()
 	id := NewHQ(c)
+	u := id.ui
 	id.ID.OWNER = pad(_testVectorOwner)
 	id.IO.HashPassONE = hashWrap512([]byte(_testVectorPassOne))
 	id.IO.HashPassTWO = hashWrap512([]byte(_testVectorPassTwo))
 	id.IO.TSS = strconv.FormatInt(id.IO.Start.Unix(), 10)
-	out("\nPrepare: [g]enerate & [u]nlock identity!")
+	u.out("\nPrepare: [g]enerate & [u]nlock identity!")
 	id.IO.Start = time.Now()
-	id.genSphincs()
+	if err := id.genSphincs(); err != nil {
+		u.errOut(err.Error())
+		return false
+	}
 	id.report()
 	id.IO.TSS = strconv.FormatInt(id.IO.Start.Unix(), 10)
 	id.IO.MSG = hashWrap512([]byte(_testVectorMessage))
 	id.IO.FileName = "<random test message>"
 	msg := multiSliceAppendSEP(id.ID.OWNER[:], id.ID.TAG[:], []byte(id.IO.TSS), id.IO.MSG[:])
 	id.IO.MSG = blake3fix(msg)
-	out("\nLooping hq [sign|verify|integ|unlock|dirMap] operations!")
-	out("\nsphincs.sign   : " + sphincsSignBench(id).String() + _OP)
-	out("\nsphincs.verify : " + sphincsVerifyBench(id).String() + _OP)
-	out("\ncube.tag       : " + cubeTagBench(id).String() + _OP)
-	out("\ncube.unlock    : " + cubeUnlockBench(id).String() + _OP)
-	// out("\nsign.dirmap    : "+dirmapSignBench().String() + _OP)
-	// out("\nverify.dirmap  : "+dirmapVerifyBench().String() + _OP)
-	out("............................................................")
-	out("total.suite      : " + time.Since(t0).String() + _OP)
+	u.out("\nLooping hq [sign|verify|integ|unlock|dirMap] operations!")
+	u.out("\nsphincs.sign   : " + sphincsSignBench(id).String() + _OP)
+	u.out("\nsphincs.verify : " + sphincsVerifyBench(id).String() + _OP)
+	u.out("\ncube.tag       : " + cubeTagBench(id).String() + _OP)
+	u.out("\ncube.unlock    : " + cubeUnlockBench(id).String() + _OP)
+	// u.out("\nsign.dirmap    : "+dirmapSignBench().String() + _OP)
+	// u.out("\nverify.dirmap  : "+dirmapVerifyBench().String() + _OP)
+	u.out("............................................................")
+	u.out("total.suite      : " + time.Since(t0).String() + _OP)
 	return true
 }
 
@@ -48,7 +52,7 @@ func sphincsSignBench(id *HQ) time.Duration {
 	t1 := time.Now()
 	for range _benchSlow {
 		_ = sphincs.Sign(id.IO.PRIVKEY, id.IO.MSG)
-		outPlain("..........")
+		id.ui.outPlain("..........")
 	}
 	return time.Since(t1) / _benchSlow
 }
@@ -58,7 +62,7 @@ func sphincsVerifyBench(id *HQ) time.Duration {
 	t1 := time.Now()
 	for range _benchFast {
 		_ = sphincs.Verify(id.ID.KEY, id.IO.MSG, id.IO.SIG)
-		outPlain(".")
+		id.ui.outPlain(".")
 	}
 	return time.Since(t1) / _benchFast
 }
@@ -68,7 +72,7 @@ func cubeTagBench(id *HQ) time.Duration {
 	t1 := time.Now()
 	for range _benchFast {
 		id.genTag()
-		outPlain(".")
+		id.ui.outPlain(".")
 	}
 	return time.Since(t1) / _benchFast
 }
@@ -87,7 +91,7 @@ func cubeUnlockBench(id *HQ) time.Duration {
 			Owner:        hashWrap512(id.ID.OWNER[:]),
 			KeyMac:       sha3fix(blake3([]byte(_hashKMAC))),
 		})
-		outPlain("..........")
+		id.ui.outPlain("..........")
 	}
 	return time.Since(t1) / _benchSlow
 }
@@ -103,7 +107,7 @@ func dirmapSignBench() time.Duration {
 		c.FileName = _BENCH_TESTDIR
 		c.Silent = true
 		_ = DirSign(c)
-		outPlain("..........")
+		u.outPlain("..........")
 	}
 	return time.Since(t1) / _benchSlow
 }
@@ -116,7 +120,7 @@ func dirmapVerifyBench() time.Duration {
 		c.FileName = _BENCH_TESTDIR
 		c.Silent = true
 		_ = DirVerify(c)
-		outPlain("..........")
+		u.outPlain("..........")
 	}
 	return time.Since(t1) / _benchSlow
 }
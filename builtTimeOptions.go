@@ -10,8 +10,12 @@ const (
 	// staticly enforce [no] color mode without env variable FORCE_COLOR=true
 	_forceNoColor = false
 
-	// staticly force to clean all pre-existing  .hqMAP.* entries before create a new one
-	_forceMapClean = true
+	// default .hqMAP retention policy [--keep-last|--keep-daily|--keep-weekly]
+	// the most recent n maps, the most recent map of the last n days|weeks are kept
+	// HQ_MAP_CLEAN=true keeps the new map only
+	_mapKeepLast   = 10
+	_mapKeepDaily  = 7
+	_mapKeepWeekly = 4
 
 	// [zstd 1-22] compression level for .hqx container [shell script compression]
 	// defaults for best results b/c maps == highEntropy
@@ -37,6 +41,11 @@ const (
 
 	// HQs shebang header
 	_sheBang = "#!/usr/bin/hq\n"
+
+	// container layout for new signatures [_containerV2: versioned, length-prefixed | _containerV1: legacy]
+	// both layouts are always accepted on verify
+	_containerVersion = _containerV2
+
 	// ###########################
 	// # SECURITY POLICY SECTION #
 	// ###########################
@@ -0,0 +1,264 @@
+package hq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// const
+const (
+	_chainPrev     = "#prev "
+	_chainPruned   = "#pruned "
+	_chainUnsigned = "-"
+)
+
+// chain link status [verify --chain]
+const (
+	_linkFirst    = "first"    // oldest map, no previous map recorded
+	_linkOK       = "linked"   // previous map present and unchanged
+	_linkPruned   = "pruned"   // previous map removed by the retention policy
+	_linkGap      = "gap"      // previous map missing, or no link recorded
+	_linkFork     = "fork"     // previous map is not the direct predecessor
+	_linkReplaced = "replaced" // previous map or its signature changed
+	_linkInvalid  = "invalid"  // map signature does not validate
+)
+
+// mapLink ...
+type mapLink struct {
+	prev   string            // previous .hqMAP [basename, "": none]
+	hash   string            // previous .hqMAP message hash
+	sig    string            // previous .hqMAP signature message hash
+	pruned map[string]string // removed .hqMAP [basename] -> message hash
+}
+
+// mapHash ...
+func mapHash(filename string) (string, error) {
+	h, err := getMSGHash(filename)
+	if err != nil {
+		return "", err
+	}
+	return string(s2hex(h[:])), nil
+}
+
+// encode ...
+func (l mapLink) encode() []byte {
+	if l.prev == "" {
+		return nil
+	}
+	b := []byte(_chainPrev + l.prev + _space + l.hash + _space + l.sig + _linefeedS)
+	for _, name := range slices.Sorted(maps.Keys(l.pruned)) {
+		b = append(b, _chainPruned+name+_space+l.pruned[name]+_linefeedS...)
+	}
+	return b
+}
+
+// readMapLink returns the chain link recorded in the header of the .hqMAP filename [v1: none]
+func readMapLink(filename string) (mapLink, error) {
+	l := mapLink{pruned: make(map[string]string)}
+	data, err := decompressReadHeader(filename)
+	if err != nil {
+		return l, err
+	}
+	f := strings.Split(strings.TrimSuffix(string(data), _linefeedS), _linefeedS)
+	if f[0] != _mapHeader || !isMapRecord(f) {
+		return l, nil
+	}
+	for _, line := range f[1:] {
+		var v []string
+		switch {
+		case strings.HasPrefix(line, _chainPrev):
+			if v = strings.Fields(line[len(_chainPrev):]); len(v) == 3 && isMapFile(v[0]) {
+				l.prev, l.hash, l.sig = v[0], v[1], v[2]
+				continue
+			}
+		case strings.HasPrefix(line, _chainPruned):
+			if v = strings.Fields(line[len(_chainPruned):]); len(v) == 2 && isMapFile(v[0]) {
+				l.pruned[v[0]] = v[1]
+				continue
+			}
+		default:
+			continue
+		}
+		return l, fmt.Errorf("%w [input map is corrupt, link: %s]", ErrCorruptContainer, line)
+	}
+	return l, nil
+}
+
+// newMapLink returns the chain link of the new .hqMAP name [basename] of dir, p is the
+// retention policy applied after the sign operation
+func newMapLink(dir, name string, p mapPolicy) (mapLink, error) {
+	l := mapLink{pruned: make(map[string]string)}
+	list, err := listMaps(dir)
+	if err != nil {
+		return l, err
+	}
+	list = slices.DeleteFunc(list, func(m string) bool { return m >= name })
+	if len(list) == 0 {
+		return l, nil
+	}
+	path := fixPath(dir)
+	l.prev = list[len(list)-1]
+	if l.hash, err = mapHash(path + l.prev); err != nil {
+		return l, err
+	}
+	if l.sig, err = mapHash(path + l.prev + _extSignature); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return l, err
+		}
+		l.sig = _chainUnsigned
+	}
+	exists := make(map[string]bool, len(list))
+	for _, m := range list {
+		exists[m] = true
+	}
+	remove := prunePlan(append(list, name), name, p)
+	last, _ := readMapLink(path + l.prev) // carries the records of all earlier removed maps
+	explain := func(target string) error {
+		switch {
+		case target == "":
+		case remove[target]:
+			h, err := mapHash(path + target)
+			if err != nil {
+				return err
+			}
+			l.pruned[target] = h
+		case !exists[target]:
+			if h, ok := last.pruned[target]; ok {
+				l.pruned[target] = h
+			}
+		}
+		return nil
+	}
+	if err := explain(l.prev); err != nil {
+		return l, err
+	}
+	for _, m := range list {
+		if remove[m] {
+			continue
+		}
+		ml, err := readMapLink(path + m)
+		if err != nil {
+			continue // reported by verify --chain
+		}
+		if err := explain(ml.prev); err != nil {
+			return l, err
+		}
+	}
+	return l, nil
+}
+
+// chain verifies the hash chain of all .hqMAP snapshots of the directory c.FileName
+func (c *Config) chain(ctx context.Context) (*Chain, error) {
+	list, err := listMaps(c.mapDir())
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("%w [%s]", ErrMapNotFound, c.mapDir())
+	}
+	path := fixPath(c.mapDir())
+	ch := &Chain{Dir: c.FileName, Links: make([]ChainLink, len(list))}
+	links := make([]mapLink, len(list))
+	exists := make(map[string]bool, len(list))
+	pruned := make(map[string]string) // records of all valid maps
+	for i, name := range list {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		exists[name] = true
+		link := &ch.Links[i]
+		link.MapInfo = c.mapInfo(name)
+		if links[i], err = readMapLink(path + name); err != nil {
+			link.Valid, link.Err = false, err
+		}
+		if link.Valid {
+			maps.Copy(pruned, links[i].pruned)
+		}
+		link.Prev = links[i].prev
+	}
+	for i, name := range list {
+		l, link := links[i], &ch.Links[i]
+		switch {
+		case !link.Valid:
+			link.Status = _linkInvalid
+		case l.prev == "" && i == 0:
+			link.Status = _linkFirst
+		case l.prev == "":
+			link.Status = _linkGap
+		case l.prev >= name, i > 0 && l.prev < list[i-1]:
+			link.Status = _linkFork
+		case exists[l.prev]:
+			link.Status = _linkOK
+			if h, err := mapHash(path + l.prev); err != nil || h != l.hash {
+				link.Status = _linkReplaced
+			}
+			if l.sig != _chainUnsigned {
+				if h, err := mapHash(path + l.prev + _extSignature); err != nil || h != l.sig {
+					link.Status = _linkReplaced
+				}
+			}
+		case pruned[l.prev] == l.hash:
+			link.Status = _linkPruned
+		default:
+			link.Status = _linkGap
+		}
+		switch link.Status {
+		case _linkGap:
+			ch.Gaps++
+		case _linkFork:
+			ch.Forks++
+		case _linkInvalid, _linkReplaced:
+			ch.Invalid++
+		}
+	}
+	if ch.Gaps+ch.Forks+ch.Invalid != 0 {
+		return ch, fmt.Errorf("%w [gaps: %d, forks: %d, invalid: %d]", ErrChainBroken, ch.Gaps, ch.Forks, ch.Invalid)
+	}
+	return ch, nil
+}
+
+// runChain [hq verify --chain <dir>]
+func (c *Config) runChain(ctx context.Context) error {
+	u := c.ui()
+	ch, err := c.chain(ctx)
+	if ch == nil {
+		if c.JSON {
+			u.out(jsonLine(jsonReport{Type: "chain", Action: "verify", Dir: c.FileName, Error: err.Error(), ErrorCode: jsonErrCode(err)}))
+		}
+		return err
+	}
+	if c.JSON {
+		for _, link := range ch.Links {
+			r := link.jsonReport("chain", c.FileName)
+			r.Prev, r.Link = link.Prev, link.Status
+			u.out(jsonLine(r))
+		}
+		r := jsonReport{Type: "chain", Action: "verify", Dir: c.FileName, Valid: err == nil, FilesTotal: uint64(len(ch.Links))}
+		if err != nil {
+			r.Error, r.ErrorCode = err.Error(), jsonErrCode(err)
+		}
+		u.out(jsonLine(r))
+		return err
+	}
+	valid, invalid := u.valid, u.fail
+	for _, link := range ch.Links {
+		switch link.Status {
+		case _linkFirst, _linkOK, _linkPruned:
+			u.out(link.line() + _space + link.Status + _space + valid)
+		case _linkInvalid:
+			u.out(link.line() + _space + link.Status + _space + invalid + " [" + link.Err.Error() + "]")
+		default:
+			u.out(link.line() + _space + link.Status + _space + invalid + " [prev: " + link.Prev + "]")
+		}
+	}
+	if err == nil && !c.Silent {
+		u.out("chain of " + strconv.Itoa(len(ch.Links)) + " .hqMAP snapshots " + valid)
+	}
+	return err
+}
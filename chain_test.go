@@ -0,0 +1,127 @@
+package hq
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// testSignDirs signs the directory c.FileName n times, one .hqMAP per time stamp
+func testSignDirs(t testing.TB, c *Config, n int, pause time.Duration) []string {
+	t.Helper()
+	for i := range n {
+		if i > 0 {
+			time.Sleep(pause)
+		}
+		if _, err := SignDir(context.Background(), c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, err := listMaps(c.FileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
+
+// testChain returns the chain link states of the directory c.FileName
+func testChain(t testing.TB, c *Config) ([]string, *Chain, error) {
+	t.Helper()
+	ch, err := VerifyChain(context.Background(), c)
+	if ch == nil {
+		t.Fatal(err)
+	}
+	var status []string
+	for _, l := range ch.Links {
+		status = append(status, l.Status)
+	}
+	return status, ch, err
+}
+
+// testSignedDir returns a config for a new directory with a single file
+func testSignedDir(t testing.TB) *Config {
+	t.Helper()
+	c := testConfig(t)
+	c.FileName = t.TempDir()
+	if err := os.WriteFile(filepath.Join(c.FileName, "a"), []byte("a"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestChainLinkedReplacedGap(t *testing.T) {
+	c := testSignedDir(t)
+	list := testSignDirs(t, c, 3, 1100*time.Millisecond)
+	status, _, err := testChain(t, c)
+	if err != nil || !slices.Equal(status, []string{_linkFirst, _linkOK, _linkOK}) {
+		t.Fatalf("intact chain: %v %v", status, err)
+	}
+
+	// modified snapshot: its own signature fails, its successor link breaks
+	f, err := os.OpenFile(filepath.Join(c.FileName, list[1]), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	status, ch, err := testChain(t, c)
+	if !errors.Is(err, ErrChainBroken) || ch.Invalid != 2 || !slices.Equal(status, []string{_linkFirst, _linkInvalid, _linkReplaced}) {
+		t.Fatalf("modified snapshot: %v %v", status, err)
+	}
+
+	// deleted snapshot
+	for _, name := range []string{list[1], list[1] + _extSignature} {
+		if err := os.Remove(filepath.Join(c.FileName, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	status, ch, err = testChain(t, c)
+	if !errors.Is(err, ErrChainBroken) || ch.Gaps != 1 || !slices.Equal(status, []string{_linkFirst, _linkGap}) {
+		t.Fatalf("deleted snapshot: %v %v", status, err)
+	}
+}
+
+func TestChainFork(t *testing.T) {
+	c := testSignedDir(t)
+	list := testSignDirs(t, c, 2, 2100*time.Millisecond)
+
+	// a copy of the first snapshot inserted between both: unlinked, the successor skips it
+	mid := list[0][:7] + strconv.FormatInt(mapUnix(list[0])+1, 10) + list[0][17:]
+	for _, ext := range []string{"", _extSignature} {
+		data, err := os.ReadFile(filepath.Join(c.FileName, list[0]+ext))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(c.FileName, mid+ext), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	status, ch, err := testChain(t, c)
+	if !errors.Is(err, ErrChainBroken) || ch.Gaps != 1 || ch.Forks != 1 || !slices.Equal(status, []string{_linkFirst, _linkGap, _linkFork}) {
+		t.Fatalf("inserted snapshot: %v %v", status, err)
+	}
+}
+
+func TestChainPruned(t *testing.T) {
+	c := testSignedDir(t)
+	c.KeepLast, c.KeepDaily, c.KeepWeekly = 2, 0, 0
+	list := testSignDirs(t, c, 4, 1100*time.Millisecond)
+	if len(list) != 2 {
+		t.Fatalf("kept %d maps", len(list))
+	}
+	status, ch, err := testChain(t, c)
+	if err != nil || !slices.Equal(status, []string{_linkPruned, _linkOK}) {
+		t.Fatalf("pruned chain: %v %v", status, err)
+	}
+	l, err := readMapLink(filepath.Join(c.FileName, list[1]))
+	if err != nil || len(l.pruned) != 1 || l.pruned[ch.Links[0].Prev] == "" {
+		t.Fatalf("tip does not explain the pruned link: %+v %v", l.pruned, err)
+	}
+}
@@ -1,67 +1,216 @@
 package hq
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"syscall"
 )
 
-// action ...
-func (c *Config) runAction() bool {
-	ok := true
+// run ...
+func (c *Config) run(ctx context.Context) error {
+	var (
+		id  *HQ
+		err error
+	)
 	switch c.Action {
 	case "sign":
 		switch c.Target {
 		case "dir":
-			ok = c.DirSign()
+			id, err = c.dirSign(ctx)
+		case "archive":
+			id, err = c.archiveSign(ctx)
 		case "file":
-			ok = c.FileSign()
+			id, err = c.fileSign(ctx)
 		case "exec":
-			ok = c.FileSignExecuteable()
+			c.IsExec = true
+			id, err = c.fileSign(ctx)
 		default:
-			panic(_errIntParser)
+			return fmt.Errorf("%s [target: %s]", _errIntParser, c.Target)
 		}
 	case "verify":
 		switch c.Target {
 		case "dir":
-			ok = c.DirVerify()
+			if c.Chain {
+				return c.runChain(ctx) // per map json report
+			}
+			id, err = c.dirVerify(ctx)
+		case "archive":
+			id, err = c.archiveVerify(ctx)
 		case "file":
-			ok = c.FileVerify()
+			id, err = c.fileVerify(ctx)
 		case "exec":
-			ok = c.FileVerifyExecuteable()
+			if c.JSON {
+				id, err = c.fileVerify(ctx) // script is part of the json report
+				break
+			}
+			err = c.execVerify(ctx)
 		default:
-			panic(_errIntParser)
+			return fmt.Errorf("%s [target: %s]", _errIntParser, c.Target)
 		}
+	case "cosign":
+		id, err = c.cosign(ctx)
+	case "generate":
+		id, err = c.generate(ctx)
+	default:
+		return c.runAction(ctx)
+	}
+	if c.JSON {
+		c.reportJSON(c.Action, id, err)
+	}
+	return err
+}
+
+// runAction runs all actions without [json] report
+func (c *Config) runAction(ctx context.Context) error {
+	var err error
+	switch c.Action {
 	case "run":
 		if c.PlainTextScript {
-			ok = c.RunExecPlain()
+			err = c.runExecPlain()
 		} else {
-			ok = c.FileVerifyExecuteable()
+			err = c.execVerify(ctx)
 		}
-	case "generate":
-		ok = c.Generate()
 	case "pwd":
-		ok = c.LegacyPass()
+		err = c.legacyPass(ctx)
+	case "export":
+		err = c.runExport()
+	case "import":
+		err = c.runImport()
+	case "trust":
+		err = c.runTrust()
+	case "revoke":
+		err = c.runRevoke(ctx)
+	case "id":
+		err = c.runID()
+	case "diff":
+		err = c.runDiff(ctx)
+	case "maps":
+		err = c.runMaps(ctx)
+	case "watch":
+		err = c.runWatch(ctx)
 	case "unlock":
-		ok = c.Unlock()
+		err = c.unlock(ctx)
 	case "lock":
-		ok = c.Lock()
+		err = c.lock(ctx)
 	case "bench":
-		ok = c.Bench()
+		if !c.bench() {
+			err = errors.New("bench failed")
+		}
 	case "test":
-		ok = c.CryptoVerify()
+		if !c.cryptoVerify() {
+			err = errors.New("cryptographic test vector validation failed")
+		}
 	case "help":
-		help()
+		c.ui().help()
 	case "version":
-		version()
+		c.ui().version()
 	default:
-		panic(_errIntParser)
+		return fmt.Errorf("%s [action: %s]", _errIntParser, c.Action)
+	}
+	return err
+}
+
+// parseFlags extracts all --<option> [value] args, os.Args keeps the positional args
+func (c *Config) parseFlags() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "run", "r":
+			return // all args belong to the executable
+		}
 	}
-	return ok
+	args := []string{os.Args[0]}
+	for i := 1; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		if arg == "--" {
+			args = append(args, os.Args[i+1:]...)
+			break
+		}
+		if len(arg) < 3 || arg[:2] != "--" {
+			args = append(args, arg)
+			continue
+		}
+		name, val, ok := strings.Cut(arg[2:], "=")
+		value := func() string {
+			if ok {
+				return val
+			}
+			if i++; i < len(os.Args) {
+				return os.Args[i]
+			}
+			c.errsyntax("option --" + name + " requires a value")
+			return ""
+		}
+		switch name {
+		case "key":
+			c.KeyFile = value()
+		case "keyring":
+			c.KeyRing = value()
+		case "as":
+			c.As = value()
+		case "json":
+			c.JSON = true
+		case "paranoid":
+			c.Paranoid = true
+		case "quick":
+			c.Quick = true
+		case "chain":
+			c.Chain = true
+		case "only":
+			c.Only = append(c.Only, value())
+		case "archive":
+			c.Archive = true
+		case "map-out", "map-in":
+			c.MapDir = value()
+			if !isDir(c.MapDir) {
+				c.errsyntax("option --" + name + " requires a directory")
+			}
+		case "follow":
+			c.Follow = true
+		case "one-fs":
+			c.OneFS = true
+		case "no-special":
+			c.NoSpecial = true
+		case "exclude":
+			c.Exclude = append(c.Exclude, value())
+		case "include":
+			c.Include = append(c.Include, value())
+		case "keep-last", "keep-daily", "keep-weekly", "max-depth", "walk-threads":
+			n, err := strconv.Atoi(value())
+			if err != nil || n < 0 {
+				c.errsyntax("option --" + name + " requires a number")
+			}
+			switch name {
+			case "keep-last":
+				c.KeepLast = n
+			case "keep-daily":
+				c.KeepDaily = n
+			case "max-depth":
+				c.MaxDepth = n
+			case "walk-threads":
+				c.WalkThreads = n
+			default:
+				c.KeepWeekly = n
+			}
+		case "threshold":
+			k, err := strconv.Atoi(value())
+			if err != nil || k < 1 {
+				c.errsyntax("option --threshold requires a positive number")
+			}
+			c.Threshold = k
+		default:
+			args = append(args, arg)
+		}
+	}
+	os.Args = args
 }
 
 // parseCmd ...
 func (c *Config) parseCmd() {
+	c.parseFlags()
 	c.FileName = "."
 	c.Target = "dir"
 	cmdargs := len(os.Args)
@@ -99,6 +248,73 @@ func (c *Config) parseCmd() {
 			c.Action = "pwd"
 			c.PwdComplex = false
 			return
+		case "export":
+			c.Action = "export"
+			c.FileName = _me
+			if cmdargs > 2 {
+				c.FileName = os.Args[2]
+			}
+			return
+		case "import":
+			c.Action = "import"
+			switch {
+			case cmdargs > 2:
+				c.FileName = os.Args[2]
+			case c.IsPipe:
+				c.FileName = _pipe
+			default:
+				c.errsyntax("import requires an armored public key <file> or pipe")
+			}
+			return
+		case "trust":
+			c.Action = "trust"
+			return
+		case "revoke":
+			c.Action = "revoke"
+			return
+		case "id":
+			c.Action = "id"
+			return
+		case "diff":
+			c.Action = "diff"
+			if cmdargs < 5 || !isDir(os.Args[2]) {
+				c.errsyntax("diff requires a signed directory and two timestamps")
+			}
+			for _, ts := range os.Args[3:5] {
+				if _, err := strconv.Atoi(ts); err != nil || len(ts) < 3 || len(ts) > 10 {
+					errExit("please sepcify digits only [min 3, max 10] , example 1634981329")
+				}
+			}
+			c.FileName = os.Args[2]
+			return
+		case "maps":
+			c.Action = "maps"
+			if cmdargs < 3 || (os.Args[2] != "list" && os.Args[2] != "ls") {
+				c.errsyntax("maps requires the list command")
+			}
+			if cmdargs > 3 {
+				c.FileName = os.Args[3]
+			}
+			if !isDir(c.FileName) {
+				c.errsyntax("maps list requires a directory")
+			}
+			return
+		case "watch", "w":
+			c.Action = "watch"
+			if cmdargs > 2 {
+				c.FileName = os.Args[2]
+			}
+			if !isDir(c.FileName) {
+				c.errsyntax("watch requires a signed directory")
+			}
+			return
+		case "cosign":
+			c.Action = "cosign"
+			if cmdargs < 3 {
+				c.errsyntax("cosign requires an .hqs container or a signed directory")
+			}
+			c.FileName = os.Args[2]
+			return
 		case "unlock", "u":
 			c.Action = "unlock"
 			return
@@ -119,22 +335,17 @@ func (c *Config) parseCmd() {
 			return
 		case ".":
 			c.Action = "verify"
-			c.Silent = true
-			if c.getMap() == "" {
+			if _, err := c.getMap(); err != nil {
 				c.Action = "sign"
 			}
-			c.FileName = "."
-			c.Silent = false
 			return
 		default:
 			c.FileName = x
 			if isDir(c.FileName) {
 				c.Action = "verify"
-				c.Silent = true
-				if c.getMap() == "" {
+				if _, err := c.getMap(); err != nil {
 					c.Action = "sign"
 				}
-				c.Silent = false
 				return
 			}
 			c.Target = "file"
@@ -165,7 +376,7 @@ func (c *Config) parseCmd() {
 			if err != nil {
 				errExit("unable to read file [" + c.FileName + "] [" + err.Error() + "]")
 			}
-			h := make([]byte, 24)
+			h := make([]byte, 32)
 			_, _ = f.Read(h)
 			f.Close()
 			head := string(h)
@@ -176,17 +387,15 @@ func (c *Config) parseCmd() {
 				return
 			default:
 			}
-			if head[:13] == "#!/usr/bin/hqx" {
-				switch head[14:20] {
-				case "##HQX#":
-					c.Action = "run"
-					c.IsExec = true
-					c.RunExec = true
-					return
-				case "##HQS#":
-					c.Action = "verify"
-					return
-				}
+			switch containerType(h) {
+			case "HQX":
+				c.Action = "run"
+				c.IsExec = true
+				c.RunExec = true
+				return
+			case "HQS":
+				c.Action = "verify"
+				return
 			}
 			c.Action = "sign"
 			return
@@ -199,7 +408,7 @@ func (c *Config) parseCmd() {
 			c.FileName = _pipe
 			return
 		}
-		errsyntax("")
+		c.errsyntax("")
 	}
 	switch {
 	case cmdargs > 2:
@@ -218,6 +427,10 @@ func (c *Config) parseCmd() {
 			return
 		}
 		c.Target = "file"
+		if c.Archive {
+			c.Target = "archive"
+			return
+		}
 		l := len(c.FileName)
 		switch c.Action[0] {
 		case 's':
@@ -2,16 +2,22 @@
 package main
 
 import (
+	"context"
 	"os"
+	"os/signal"
 
 	"paepcke.de/hq"
 )
 
 // main ..
 func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 	c := hq.NewConfig()
 	c.ParseCmd()
-	if !c.RunAction() {
+	err := c.Run(ctx)
+	stop()
+	if err != nil {
+		os.Stderr.WriteString("ERROR: " + err.Error() + "\n")
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,256 @@
+package hq
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//
+// CONTAINER LAYOUT
+//
+// v1 [legacy]: #!/usr/bin/hq\n##<HQS|HQX>#<token>#<TAG>#<TSS:10>#<base64(SIG[|SCRIPT])>#[\n#HQC#...# co-signatures]
+// v2         : #!/usr/bin/hq\n##HQV#<version>#<HQS|HQX>#<base64(fields)>#
+//
+// v2 fields are [id byte][uvarint length][value], unknown field ids are skipped,
+// every signer record carries its own algorithm identifier, the first signer is the primary
+//
+
+// const
+const (
+	_containerV1     = 1
+	_containerV2     = 2
+	_containerMagic  = "##HQV#"
+	_algSphincsBlake = "sphincs256-blake3-512"
+)
+
+// v2 container field ids
+const (
+	_fieldExecToken byte = iota + 1 // 6 byte exec token [HQX]
+	_fieldSigner                    // signer record, repeated [primary first, then co-signatures]
+	_fieldScript                    // compressed exec payload [HQX]
+)
+
+// v2 signer record field ids
+const (
+	_fieldTag       byte = iota + 1 // NameTAG
+	_fieldTSS                       // time stamp [unix seconds, decimal, fits int64]
+	_fieldAlgorithm                 // signature algorithm identifier
+	_fieldSig                       // raw signature
+)
+
+// appendField ...
+func appendField(b []byte, fid byte, value []byte) []byte {
+	b = append(b, fid)
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+// readFields calls fn for every [id|length|value] field in b
+func readFields(b []byte, fn func(fid byte, value []byte) error) error {
+	for len(b) > 0 {
+		fid := b[0]
+		l, n := binary.Uvarint(b[1:])
+		if n <= 0 || l > uint64(len(b)-1-n) {
+			return fmt.Errorf("%w [truncated field %d]", ErrCorruptContainer, fid)
+		}
+		b = b[1+n:]
+		if err := fn(fid, b[:l]); err != nil {
+			return err
+		}
+		b = b[l:]
+	}
+	return nil
+}
+
+// containerType returns HQS|HQX for a [v1|v2] container head, empty if unknown
+func containerType(head []byte) string {
+	if !bytes.HasPrefix(head, []byte(_sheBang)) {
+		return ""
+	}
+	f := strings.Split(string(head[len(_sheBang):]), "#")
+	switch {
+	case len(f) > 4 && f[2] == "HQV":
+		return f[4]
+	case len(f) > 2 && (f[2] == "HQS" || f[2] == "HQX"):
+		return f[2]
+	}
+	return ""
+}
+
+// encodeSigner ...
+func encodeSigner(cs Cosignature) []byte {
+	var b []byte
+	b = appendField(b, _fieldTag, cs.TAG[:])
+	b = appendField(b, _fieldTSS, []byte(cs.TSS))
+	b = appendField(b, _fieldAlgorithm, []byte(_algSphincsBlake))
+	return appendField(b, _fieldSig, cs.SIG[:])
+}
+
+// decodeSigner ...
+func decodeSigner(b []byte) (Cosignature, error) {
+	var (
+		cs  Cosignature
+		alg string
+		sig []byte
+		tag []byte
+	)
+	err := readFields(b, func(fid byte, value []byte) error {
+		switch fid {
+		case _fieldTag:
+			tag = value
+		case _fieldTSS:
+			cs.TSS = string(value)
+		case _fieldAlgorithm:
+			alg = string(value)
+		case _fieldSig:
+			sig = value
+		}
+		return nil
+	})
+	switch {
+	case err != nil:
+		return cs, err
+	case alg != _algSphincsBlake:
+		return cs, fmt.Errorf("%w [unsupported signature algorithm %q]", ErrCorruptContainer, alg)
+	case len(tag) != len(cs.TAG):
+		return cs, fmt.Errorf("%w [signer name tag]", ErrCorruptContainer)
+	case len(sig) != SignatureSize:
+		return cs, fmt.Errorf("%w [signature size]", ErrCorruptContainer)
+	}
+	if _, err := parseTSS(cs.TSS); err != nil {
+		return cs, fmt.Errorf("%w [unable to parse timestamp]", ErrCorruptContainer)
+	}
+	copy(cs.TAG[:], tag)
+	copy(cs.SIG[:], sig)
+	return cs, nil
+}
+
+// encodeSigV2 ...
+func (id *HQ) encodeSigV2() []byte {
+	var body []byte
+	kind := "HQS"
+	if id.IO.IsExec {
+		kind = "HQX"
+		body = appendField(body, _fieldExecToken, []byte(id.IO.TokenExec))
+	}
+	body = appendField(body, _fieldSigner, encodeSigner(Cosignature{TAG: id.ID.TAG, TSS: id.IO.TSS, SIG: id.IO.SIG}))
+	for _, cs := range id.IO.COSIG {
+		body = appendField(body, _fieldSigner, encodeSigner(cs))
+	}
+	if id.IO.IsExec {
+		body = appendField(body, _fieldScript, id.IO.SCRIPT)
+	}
+	return multiSliceAppendSEP([]byte(_sheBang+_containerMagic[:len(_containerMagic)-1]), []byte(strconv.Itoa(_containerV2)), []byte(kind), []byte(base64.StdEncoding.EncodeToString(body)))
+}
+
+// decodeSigV2 decodes the container part following the magic [<version>#<HQS|HQX>#<base64>#]
+func (id *HQ) decodeSigV2(in []byte) error {
+	f := strings.Split(strings.TrimRight(string(in), _linefeedS), "#")
+	if len(f) != 4 || f[3] != "" {
+		return ErrCorruptContainer
+	}
+	if f[0] != strconv.Itoa(_containerV2) {
+		return fmt.Errorf("%w [unsupported container version %q]", ErrCorruptContainer, f[0])
+	}
+	switch f[1] {
+	case "HQS":
+	case "HQX":
+		id.IO.IsExec = true
+	default:
+		return fmt.Errorf("%w [unknown container type %q]", ErrCorruptContainer, f[1])
+	}
+	body, err := base64.StdEncoding.DecodeString(f[2])
+	if err != nil {
+		return fmt.Errorf("%w [container base64 decode error]", ErrCorruptContainer)
+	}
+	var signers []Cosignature
+	err = readFields(body, func(fid byte, value []byte) error {
+		switch fid {
+		case _fieldExecToken:
+			id.IO.TokenExec = matchShebang(string(value)).token
+		case _fieldSigner:
+			cs, err := decodeSigner(value)
+			if err != nil {
+				return err
+			}
+			signers = append(signers, cs)
+		case _fieldScript:
+			id.IO.SCRIPT = value
+		}
+		return nil
+	})
+	switch {
+	case err != nil:
+		return err
+	case len(signers) == 0:
+		return fmt.Errorf("%w [no signer]", ErrCorruptContainer)
+	case id.IO.IsExec && id.IO.SCRIPT == nil:
+		return fmt.Errorf("%w [exec payload missing]", ErrCorruptContainer)
+	}
+	if err = id.signerKey(string(signers[0].TAG[:])); err != nil {
+		return err
+	}
+	id.IO.TSS, id.IO.SIG, id.IO.COSIG = signers[0].TSS, signers[0].SIG, signers[1:]
+	return nil
+}
+
+// encodeSigV1 encodes the legacy fixed offset layout
+func (id *HQ) encodeSigV1() ([]byte, error) {
+	if len(id.IO.TSS) != 10 {
+		return nil, errors.New("time stamp [" + id.IO.TSS + "] not representable within a legacy v1 container")
+	}
+	prefix := []byte("#HQS#@@@@@@")
+	sig := id.IO.SIG[:]
+	if id.IO.IsExec {
+		prefix = []byte("#HQX#" + id.IO.TokenExec)
+		sig = append(sig, id.IO.SCRIPT...)
+	}
+	sig = []byte(base64.StdEncoding.EncodeToString(sig))
+	return append(multiSliceAppendSEP([]byte(_sheBang), prefix, id.ID.TAG[:], []byte(id.IO.TSS), sig), id.encodeCosigs()...), nil
+}
+
+// decodeSigV1 decodes the legacy fixed offset layout
+func (id *HQ) decodeSigV1(filesig []byte) error {
+	var err error
+	if i := bytes.Index(filesig, []byte(_cosignMarker)); i > 0 {
+		if err = id.decodeCosigs(filesig[i+1:]); err != nil {
+			return err
+		}
+		filesig = filesig[:i:i] // fixed offsets never reach into the co-signature block
+	}
+	if len(filesig) < 70 {
+		return ErrCorruptContainer
+	}
+	switch string(filesig[16:19]) {
+	case "HQS":
+	case "HQX":
+		id.IO.IsExec = true
+		s := matchShebang(string(filesig[20:26]))
+		id.IO.TokenExec = s.token
+	default:
+		return ErrCorruptContainer
+	}
+	if err = id.signerKey(string(filesig[27:57])); err != nil {
+		return err
+	}
+	id.IO.TSS = string(filesig[58:68])
+	if _, err = parseTSS(id.IO.TSS); err != nil {
+		return fmt.Errorf("%w [unable to parse timestamp]", ErrCorruptContainer)
+	}
+	if filesig, err = base64.StdEncoding.DecodeString(string(filesig[69 : len(filesig)-1])); err != nil {
+		return fmt.Errorf("%w [signature base64 decode error]", ErrCorruptContainer)
+	}
+	if len(filesig) < SignatureSize {
+		return fmt.Errorf("%w [signature truncated]", ErrCorruptContainer)
+	}
+	copy(id.IO.SIG[:], filesig)
+	if id.IO.IsExec {
+		id.IO.SCRIPT = filesig[SignatureSize:]
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package hq
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDecodeSignerTSS(t *testing.T) {
+	for _, tss := range []string{"", "-1", "+1700000000", " 1700000000", "1e9", "9223372036854775808", "18446744073709551615", "00000000000000000001"} {
+		b := encodeSigner(Cosignature{TSS: tss})
+		if _, err := decodeSigner(b); !errors.Is(err, ErrCorruptContainer) {
+			t.Errorf("TSS %q: expected ErrCorruptContainer, got %v", tss, err)
+		}
+	}
+	if _, err := decodeSigner(encodeSigner(Cosignature{TSS: "9223372036854775807"})); err != nil {
+		t.Errorf("max int64 TSS: %v", err)
+	}
+}
+
+func TestVerifyMalformedTSS(t *testing.T) {
+	ctx := context.Background()
+	c := testConfig(t)
+	c.FileName = testFile(t, "malformed tss")
+	if _, err := Sign(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	sigName := c.FileName + _extSignature
+	filesig, err := os.ReadFile(sigName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := NewHQ(c)
+	if err = id.decodeSig(filesig); err != nil {
+		t.Fatal(err)
+	}
+	id.IO.TSS = "18446744073709551615" // > max int64
+	if err = os.WriteFile(sigName, id.encodeSigV2(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	v := *c
+	v.FileName = sigName
+	if _, err = Verify(ctx, &v); !errors.Is(err, ErrCorruptContainer) {
+		t.Fatalf("expected ErrCorruptContainer, got %v", err)
+	}
+}
+
+func TestDecodeSigV1Truncated(t *testing.T) {
+	tag := strings.Repeat("A", len(ID{}.TAG))
+	// after "##HQS" the co-signature tag lands on the primary tag offset [27:57]
+	cosig := _cosignMarker + "x#" + tag + "#1700000000#" + base64.StdEncoding.EncodeToString(make([]byte, SignatureSize)) + "#"
+	for _, primary := range []string{"", "##HQS", "##HQS#@@@@@@#" + tag, "##HQS#@@@@@@#" + tag + "#1700000000#"} {
+		for _, tail := range []string{"", cosig} {
+			filesig := []byte(_sheBang + primary + tail)
+			id := NewHQ(NewConfig())
+			id.IO.KeyPinned = true // the fixed offsets are read, not a keystore lookup
+			copy(id.ID.TAG[:], tag)
+			if err := id.decodeSig(filesig); !errors.Is(err, ErrCorruptContainer) {
+				t.Errorf("%q: expected ErrCorruptContainer, got %v", filesig, err)
+			}
+		}
+	}
+}
@@ -5,11 +5,14 @@ package hq
 import (
 	"encoding/base32"
 	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
-	"strconv"
 	"strings"
 	"syscall"
 
+	"golang.org/x/term"
 	"paepcke.de/codereview"
 )
 
@@ -23,13 +26,9 @@ const (
 	_signifyPubExt     = ".signify.pub"
 )
 
-// var
-var (
-	cr = codereview.NewConfigBatch()
-)
-
 // codeReviewHash ...
 func codeReviewHash(filename string) (bool, []byte) {
+	cr := codereview.NewConfigBatch() // per call, hash workers run concurrently
 	cr.Path = filename
 	r := cr.ParseFile()
 	return r.Found, r.Result
@@ -51,47 +50,90 @@ func (id *HQ) genTag() {
 }
 
 // passEntry ...
-func (id *HQ) passEntry(reason string) {
-	if id.readUnlockedKey(); id.IO.UnlockedKey {
+func (id *HQ) passEntry(reason string) error {
+	if err := id.readUnlockedKey(); err != nil {
+		return err
+	}
+	if id.IO.UnlockedKey {
 		if _allowUnlockViaEnv {
-			return
+			return nil
+		}
+		if !id.IO.Silent {
+			id.ui.errOut("unlocked key found, but function disable via build-time security policy, [enter credentials]")
 		}
-		errOut("unlocked key found, but function disable via build-time security policy, [enter credentials]")
 	}
 	if !id.IO.PwdEnv {
-		if id.IO.ColorUI {
-			bON, cOFF = _Blue, _Off
+		if !term.IsTerminal(0) {
+			return ErrPassphraseRequired
 		}
-		repeat := true
+		u, repeat := id.ui, true
 		if reason != "create hq identity" {
 			repeat = false
-			out(bON + "# Please unlock your HQ Identity! [" + reason + "]" + cOFF)
+			u.prompt(u.bON + "# Please unlock your HQ Identity! [" + reason + "]" + u.cOFF + _linefeedS)
 			id.IO.ReportTime = false
 			id.report()
 		}
-		id.IO.HashPassONE = passEntryHash("ONE", true, repeat)
-		id.IO.HashPassTWO = passEntryHash("TWO", true, repeat)
+		var err error
+		if id.IO.HashPassONE, err = u.passEntryHash("ONE", true, repeat); err != nil {
+			return err
+		}
+		if id.IO.HashPassTWO, err = u.passEntryHash("TWO", true, repeat); err != nil {
+			return err
+		}
 		id.IO.ReportTime = true
 	}
 	if id.IO.HashPassONE == id.IO.HashPassTWO {
-		errExit("Password ONE an TWO can not be the same!")
+		return fmt.Errorf("%w [password ONE an TWO can not be the same]", ErrPassphrase)
 	}
+	return nil
+}
+
+// setPass ...
+func (id *HQ) setPass(c *Config) {
+	if c.PassONE == "" && c.PassTWO == "" {
+		return
+	}
+	id.IO.HashPassONE = hashWrap512([]byte(c.PassONE))
+	id.IO.HashPassTWO = hashWrap512([]byte(c.PassTWO))
+	id.IO.PwdEnv = true
 }
 
 // unlockHQ ...
-func (id *HQ) unlockHQ() {
+func (id *HQ) unlockHQ() error {
 	if id.IO.UnlockedKey {
-		return
+		return nil
 	}
 	pubkey := id.ID.KEY
-	id.genSphincs()
+	if err := id.genSphincs(); err != nil {
+		return err
+	}
 	switch {
 	case id.ID.KEY != pubkey:
-		errExit("Passwords do not match, unable to unlock!")
-	case id.IO.ColorUI:
-		unlock = _Unlock
+		return ErrPassphrase
+	case id.IO.Silent:
+		return nil
+	}
+	id.ui.out(id.ui.unlock)
+	return nil
+}
+
+// signAs returns the requested sign identity [--as], defaults to the me link
+func (id *HQ) signAs() string {
+	if id.IO.SignAs != "" {
+		return id.IO.SignAs
 	}
-	out(unlock)
+	return _me
+}
+
+// prepSign ...
+func (id *HQ) prepSign(reason string) error {
+	if err := id.readPublicKey(id.signAs()); err != nil {
+		return err
+	}
+	if err := id.passEntry(reason); err != nil {
+		return err
+	}
+	return id.unlockHQ()
 }
 
 //
@@ -105,35 +147,46 @@ func isMapOnly() bool {
 
 // isMapClean ...
 func isMapClean() bool {
-	if isEnv(_envHQMapClean) || _forceMapClean {
+	if isEnv(_envHQMapClean) {
 		return true
 	}
 	return false
 }
 
-// getKeyStore ...
-func getKeyStore() string {
+// keyStore returns the [--keyring] keystore path, defaults to ~/.hq/
+func (id *HQ) keyStore() (string, error) {
+	if id.IO.KeyStore != "" {
+		return id.IO.KeyStore, nil
+	}
 	keystore, err := os.UserHomeDir()
 	if err != nil {
-		errExit("unable find homedirectory")
+		return "", fmt.Errorf("unable find homedirectory [%w]", err)
 	}
-	return keystore + "/.hq/"
+	return keystore + "/.hq/", nil
 }
 
 // getOwnerEnv ...
-func getOwnerEnv() string {
+func getOwnerEnv() (string, error) {
 	own, ok := syscall.Getenv(_envHQOWNER)
 	if !ok {
-		return _empty
+		return _empty, nil
 	}
+	if err := validateOwner(own); err != nil {
+		return _empty, err
+	}
+	return own, nil
+}
+
+// validateOwner ...
+func validateOwner(own string) error {
 	l := len(own)
 	if l < 6 || l > 64 {
-		errExit(_errOwnerSize)
+		return errors.New(_errOwnerSize)
 	}
 	if strings.Contains(own, "=") {
-		errExit(_errOwnerCharacter)
+		return errors.New(_errOwnerCharacter)
 	}
-	return own
+	return nil
 }
 
 //
@@ -141,146 +194,181 @@ func getOwnerEnv() string {
 //
 
 // writeSig ...
-func (id *HQ) writeSig() {
-	ext, prefix := _extSignature, []byte("#HQS#@@@@@@")
-	sig := id.IO.SIG[:]
+func (id *HQ) writeSig() error {
+	sig, err := id.encodeSig()
+	if err != nil {
+		return err
+	}
+	ext := _extSignature
 	if id.IO.IsExec {
-		s := matchShebang(id.IO.TokenExec)
-		id.IO.TokenExec = s.token
-		if len(id.IO.TokenExec) != 6 {
-			errExit("unknown TokenExec")
-		}
-		ext, prefix = _extExecutable, []byte("#HQX#"+id.IO.TokenExec)
-		sig = append(sig, id.IO.SCRIPT...)
+		ext = _extExecutable
 		id.IO.FileName = id.IO.FileName[:len(id.IO.FileName)-id.IO.ScriptExtL]
 	}
-	sig = []byte(base64.StdEncoding.EncodeToString(sig))
-	sig = multiSliceAppendSEP([]byte(_sheBang), prefix, id.ID.TAG[:], []byte(id.IO.TSS), sig)
 	if err := os.WriteFile(id.IO.FileName+ext, sig, 0o770); err != nil {
-		errExit("unable to write signature :" + id.IO.FileName + ext)
+		return fmt.Errorf("unable to write signature [%s] [%w]", id.IO.FileName+ext, err)
 	}
 	if id.IO.SIGNIFYFILE != nil {
 		if err := os.WriteFile(id.IO.FileName+_extSignify, id.IO.SIGNIFYFILE, 0o770); err != nil {
-			errExit("unable to write signify sig :" + id.IO.FileName + _extSignify)
+			return fmt.Errorf("unable to write signify sig [%s] [%w]", id.IO.FileName+_extSignify, err)
 		}
 	}
+	return nil
 }
 
-// parseSig ...
-func (id *HQ) parseSig(c *Config) {
-	var err error
-	filesig := id.getSig(c)
-	switch string(filesig[16:19]) {
-	case "HQS":
-	case "HQX":
-		id.IO.IsExec = true
-		s := matchShebang(string(filesig[20:26]))
+// encodeSig encodes the container in the build-time selected [_containerVersion] layout
+func (id *HQ) encodeSig() ([]byte, error) {
+	if id.IO.IsExec {
+		s := matchShebang(id.IO.TokenExec)
 		id.IO.TokenExec = s.token
-	default:
-		errExit("defective .hqs/.hqx file or pipe container")
+		if len(id.IO.TokenExec) != 6 {
+			return nil, errors.New("unknown TokenExec")
+		}
 	}
-	id.readPublicKey(string(filesig[27:57]))
-	id.IO.TSS = string(filesig[58:68])
-	if _, err = strconv.ParseInt(string(id.IO.TSS), 10, 0); err != nil {
-		errExit("unable to parse timestamp")
+	if _containerVersion == _containerV1 {
+		return id.encodeSigV1()
 	}
-	if filesig, err = base64.StdEncoding.DecodeString(string(filesig[69 : len(filesig)-1])); err != nil {
-		errExit("signature base64 decode error")
+	return id.encodeSigV2(), nil
+}
+
+// parseSig ...
+func (id *HQ) parseSig(c *Config) error {
+	filesig, err := id.getSig(c)
+	if err != nil {
+		return err
 	}
-	copy(id.IO.SIG[:], filesig)
-	switch {
-	case id.IO.IsExec:
-		id.IO.SCRIPT = filesig[41000:]
-	default:
-		id.IO.MSG = getMSGHash(id.IO.FileName[:len(id.IO.FileName)-4])
+	if err = id.decodeSig(filesig); err != nil {
+		return err
 	}
+	if !id.IO.IsExec {
+		id.IO.MSG, err = getMSGHash(id.IO.FileName[:len(id.IO.FileName)-4])
+	}
+	return err
+}
+
+// decodeSig decodes a versioned [v2] or legacy [v1] container
+func (id *HQ) decodeSig(filesig []byte) error {
+	if len(filesig) < len(_sheBang)+len(_containerMagic) || string(filesig[:len(_sheBang)]) != _sheBang {
+		return ErrCorruptContainer
+	}
+	if string(filesig[len(_sheBang):len(_sheBang)+len(_containerMagic)]) == _containerMagic {
+		return id.decodeSigV2(filesig[len(_sheBang)+len(_containerMagic):])
+	}
+	return id.decodeSigV1(filesig)
 }
 
 // getSig ...
-func (id *HQ) getSig(c *Config) []byte {
+func (id *HQ) getSig(c *Config) ([]byte, error) {
 	if c.IsPipe {
-		return []byte(getPipe())
+		return getPipe()
 	}
-	return readFileErrExit(id.IO.FileName)
+	return readFile(id.IO.FileName)
 }
 
 // writePublicKey ...
-func (id *HQ) writePublicKey() {
-	keystore := getKeyStore()
+func (id *HQ) writePublicKey() error {
+	keystore, err := id.keyStore()
+	if err != nil {
+		return err
+	}
 	if err := os.MkdirAll(keystore[:len(keystore)-1], 0o700); err != nil {
-		errExit("unable to create" + keystore)
+		return fmt.Errorf("unable to create [%s] [%w]", keystore, err)
 	}
 	filename := keystore + string(id.ID.TAG[:])
 	key := append(id.ID.OWNER[:], []byte(base64.StdEncoding.EncodeToString(id.ID.KEY[:]))...)
-	writeFileErrExit(filename, key, 0o500)
+	if err := writeFileSync(filename, key, 0o500); err != nil {
+		return err
+	}
 	if id.IO.SetMe {
-		_ = os.Remove(keystore + "me")
-		if err := os.Symlink(filename, keystore+"me"); err != nil {
-			errExit("unable to set me tag symbolic link [" + keystore + "me]")
+		if err := setMe(keystore, filename); err != nil {
+			return err
 		}
 	}
 	if id.IO.SIGNIFYPUB != nil {
-		writeFileErrExit(filename+_signifyPubExt, id.IO.SIGNIFYPUB, 0o440)
+		return writeFileSync(filename+_signifyPubExt, id.IO.SIGNIFYPUB, 0o440)
+	}
+	return nil
+}
+
+// setMe points the keystore me link to the public key filename
+func setMe(keystore, filename string) error {
+	_ = os.Remove(keystore + _me)
+	if err := os.Symlink(filename, keystore+_me); err != nil {
+		return fmt.Errorf("unable to set me tag symbolic link [%s] [%w]", keystore+_me, err)
 	}
+	return nil
 }
 
 // writeUnlockedKey ...
-func (id *HQ) writeUnlockedKey() {
-	keystore := getKeyStore()
-	if err := os.MkdirAll(keystore[:len(keystore)-1], 0o700); err != nil {
-		errExit("unable to create" + keystore)
+func (id *HQ) writeUnlockedKey() error {
+	keystore, err := id.keyStore()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(keystore+".unlocked", 0o700); err != nil {
+		return fmt.Errorf("unable to create [%s] [%w]", keystore+".unlocked", err)
 	}
 	filename := keystore + ".unlocked/" + string(id.ID.TAG[:])
-	writeFileErrExit(filename, []byte(base64.StdEncoding.EncodeToString(id.IO.PRIVKEY[:])), 0o400)
+	return writeFileSync(filename, []byte(base64.StdEncoding.EncodeToString(id.IO.PRIVKEY[:])), 0o400)
 }
 
 // wipeUnlockedKey ...
-func (id *HQ) wipeUnlockedKey() bool {
-	keystore := getKeyStore()
+func (id *HQ) wipeUnlockedKey() error {
+	keystore, err := id.keyStore()
+	if err != nil {
+		return err
+	}
 	filename := keystore + ".unlocked/" + string(id.ID.TAG[:])
 	var blind [1452]byte
 	for i := range blind {
 		blind[i] = '0' // simply zero-out, assume non-permanent, non-journaled,  memory-backend storage backend [eg. tmpfs]
 	}
-	writeFileErrExit(filename, blind[:], 0o600)
+	if err := writeFileSync(filename, blind[:], 0o600); err != nil {
+		return err
+	}
 	os.Remove(filename)
-	id.readUnlockedKey()
-	if !id.IO.UnlockedKey {
-		if id.IO.ColorUI {
-			lock = _Lock
-		}
-		out(lock)
-		return true
+	if err := id.readUnlockedKey(); err != nil {
+		return err
 	}
-	errOut("Unlocked key removal failed!")
-	return false
+	if id.IO.UnlockedKey {
+		return errors.New("unlocked key removal failed")
+	}
+	if !id.IO.Silent {
+		id.ui.out(id.ui.lock)
+	}
+	return nil
 }
 
 // readUnlockedKey ...
-func (id *HQ) readUnlockedKey() {
+func (id *HQ) readUnlockedKey() error {
 	id.IO.UnlockedKey = false
-	keystore := getKeyStore()
+	keystore, err := id.keyStore()
+	if err != nil {
+		return err
+	}
 	filename := keystore + ".unlocked/" + string(id.ID.TAG[:])
 	key, err := os.ReadFile(filename)
 	if err != nil {
-		return
+		return nil
 	}
 	s, err := base64.StdEncoding.DecodeString(string(key))
 	if err != nil {
-		errOut("unable to decode unlocked key [" + filename + "]")
-		return
+		return fmt.Errorf("%w [unable to decode unlocked key %s]", ErrCorruptKey, filename)
 	}
 	copy(id.IO.PRIVKEY[:], []byte(s))
 	id.IO.UnlockedKey = true
+	return nil
 }
 
 // readPublicKey ...
-func (id *HQ) readPublicKey(nametag string) {
+func (id *HQ) readPublicKey(nametag string) error {
 	var (
-		err    error
-		key, k []byte
+		err error
+		key []byte
 	)
-	keystore := getKeyStore()
+	keystore, err := id.keyStore()
+	if err != nil {
+		return err
+	}
 	if nametag == "me" {
 		nametag, err = os.Readlink(keystore + "me")
 		if err != nil {
@@ -290,24 +378,88 @@ func (id *HQ) readPublicKey(nametag string) {
 	if len(nametag) > 30 {
 		nametag = nametag[len(nametag)-30:]
 	}
-	key = readFileErrExit(keystore + nametag)
-	copy(id.ID.OWNER[:], key)
-	if k, err = base64.StdEncoding.DecodeString(string(key[64:])); err != nil {
-		errExit("unable to decode key, base64 key part is defect" + keystore + string(id.ID.TAG[:]))
+	if key, err = os.ReadFile(keystore + nametag); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%w [%s]", ErrKeyNotFound, keystore+nametag)
+		}
+		return fmt.Errorf("unable to read key [%s] [%w]", keystore+nametag, err)
+	}
+	if err = id.parsePublicKey(key); err != nil {
+		return fmt.Errorf("%w [%s]", err, keystore+nametag)
 	}
-	copy(id.ID.KEY[:], k)
-	id.genTag()
 	if nametag != "me" {
 		if string(id.ID.TAG[:]) != nametag {
-			errExit("key integrity problem, tag checksum missmatch")
+			return fmt.Errorf("%w [%s]", ErrTagChecksum, nametag)
 		}
 	}
+	return nil
+}
+
+// parsePublicKey parses a keystore [OWNER || base64(KEY)] or armored public key and sets the NameTAG
+func (id *HQ) parsePublicKey(key []byte) error {
+	if isArmor(key, _armorKey) {
+		_, err := id.decodeKeyArmor(key)
+		return err
+	}
+	if len(key) < HashSize {
+		return ErrCorruptKey
+	}
+	k, err := base64.StdEncoding.DecodeString(string(key[HashSize:]))
+	if err != nil || len(k) != PublicKeySize {
+		return fmt.Errorf("%w [base64 key part is defect]", ErrCorruptKey)
+	}
+	copy(id.ID.OWNER[:], key)
+	copy(id.ID.KEY[:], k)
+	id.genTag()
+	return nil
+}
+
+// pinKey sets an explicit [c.Key|c.KeyFile] verify key, the keystore is not consulted,
+// a single pinned signer can never meet a threshold > 1
+func (id *HQ) pinKey(c *Config) error {
+	if (c.Key != nil || c.KeyFile != "") && c.Threshold > 1 {
+		return fmt.Errorf("%w [a pinned key is a single signer, threshold %d requires the keystore]", ErrThreshold, c.Threshold)
+	}
+	switch {
+	case c.Key != nil:
+		id.ID.OWNER, id.ID.KEY = c.Key.OWNER, c.Key.KEY
+		id.genTag()
+		if c.Key.TAG != [30]byte{} && c.Key.TAG != id.ID.TAG {
+			return fmt.Errorf("%w [%s]", ErrTagChecksum, string(c.Key.TAG[:]))
+		}
+	case c.KeyFile != "":
+		key, err := readFile(c.KeyFile)
+		if err != nil {
+			return err
+		}
+		if err = id.parsePublicKey(key); err != nil {
+			return fmt.Errorf("%w [%s]", err, c.KeyFile)
+		}
+	default:
+		return nil
+	}
+	id.IO.KeyPinned = true
+	return nil
+}
+
+// signerKey loads the public key for the container nametag, from the keystore or the pinned key
+func (id *HQ) signerKey(nametag string) error {
+	if !id.IO.KeyPinned {
+		return id.readPublicKey(nametag)
+	}
+	if string(id.ID.TAG[:]) != nametag {
+		return fmt.Errorf("%w [signed by %s, pinned key is %s]", ErrKeyNotFound, nametag, string(id.ID.TAG[:]))
+	}
+	return nil
 }
 
 // getMap
-func (c *Config) getMap() string {
+func (c *Config) getMap() (string, error) {
 	var curr string
-	dir := readDir(c.FileName)
+	dir, err := readDir(c.mapDir())
+	if err != nil {
+		return "", err
+	}
 	offset, term, name := 0, ".hqMAP.", ""
 	if c.TargetTS != "" {
 		offset = len(c.TargetTS)
@@ -321,50 +473,32 @@ func (c *Config) getMap() string {
 			}
 		}
 	}
-	switch {
-	case curr == "" && !c.Silent:
-		errExit("unable to find a .hqMAP [" + term + "]")
-	case curr == "":
-		return ""
-	case c.FileName == ".":
-		return curr
-	}
-	return c.FileName + "/" + curr
+	if curr == "" {
+		return "", fmt.Errorf("%w [%s]", ErrMapNotFound, term)
+	}
+	return fixPath(c.mapDir()) + curr, nil
 }
 
-// pwdTarget
-func (id *HQ) pwdTarget(c *Config) {
-	if len(os.Args) > 2 {
-		c.PwdService = os.Args[2]
-		l := len(c.PwdService)
-		if l != 0 {
-			if l > 4 || l < 256 {
-				id.IO.MSG = hashWrap512([]byte(c.PwdService))
-				return
-			}
-			errExit("The pwd and lpwd option need to specify an <target> must be more than 4 & less than 256 characters!")
-			return
-		}
+// mapDir returns the .hqMAP directory of the signed directory c.FileName
+func (c *Config) mapDir() string {
+	if c.MapDir != "" {
+		return c.MapDir
 	}
-	errExit("The pwd and lpwd option need to specify an <target> to generate an password, example:: hq pwd gmail.com")
+	return c.FileName
 }
 
-// cleanMapFiles ...
-func cleanMapFiles(path string) {
-	list := readDir(path)
-	for _, filename := range list {
-		name := filename.Name()
-		switch {
-		case name[0] != '.':
-			return // fail early, fail cheap [its a sorted list]
-		case name[1] != 'h':
-			continue
-		case len(name) < 39:
-			continue
-		case name[:6] == ".hqMAP":
-			if err := syscall.Unlink(path + "/" + name); err != nil {
-				errOut("unlik fail" + name + " " + err.Error())
-			}
+// pwdTarget
+func (id *HQ) pwdTarget(c *Config) error {
+	if c.PwdService == "" && len(os.Args) > 2 {
+		c.PwdService = os.Args[2]
+	}
+	l := len(c.PwdService)
+	if l != 0 {
+		if l > 4 || l < 256 {
+			id.IO.MSG = hashWrap512([]byte(c.PwdService))
+			return nil
 		}
+		return errors.New("the pwd and lpwd option need to specify an <target> must be more than 4 & less than 256 characters")
 	}
+	return errors.New("the pwd and lpwd option need to specify an <target> to generate an password, example:: hq pwd gmail.com")
 }
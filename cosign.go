@@ -0,0 +1,195 @@
+package hq
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// const
+const (
+	_cosignMarker = "\n#HQC#"
+	_cosignPrefix = _cosignMarker + "@@@@@@"
+)
+
+// encodeCosigs encodes all co-signatures as [\n#HQC#@@@@@@#TAG#TSS#base64(SIG)#] blocks
+func (id *HQ) encodeCosigs() []byte {
+	var b []byte
+	for _, cs := range id.IO.COSIG {
+		sig := []byte(base64.StdEncoding.EncodeToString(cs.SIG[:]))
+		b = append(b, multiSliceAppendSEP([]byte(_cosignPrefix), cs.TAG[:], []byte(cs.TSS), sig)...)
+	}
+	return b
+}
+
+// decodeCosigs parses the co-signature blocks following the primary container
+func (id *HQ) decodeCosigs(blocks []byte) error {
+	id.IO.COSIG = nil
+	for block := range bytes.SplitSeq(blocks, []byte(_linefeedS)) {
+		f := strings.Split(string(block), "#")
+		if len(f) != 7 || f[0] != "" || f[1] != "HQC" || len(f[3]) != len(ID{}.TAG) || f[6] != "" {
+			return fmt.Errorf("%w [co-signature block]", ErrCorruptContainer)
+		}
+		if _, err := parseTSS(f[4]); err != nil {
+			return fmt.Errorf("%w [unable to parse co-signature timestamp]", ErrCorruptContainer)
+		}
+		sig, err := base64.StdEncoding.DecodeString(f[5])
+		if err != nil || len(sig) != SignatureSize {
+			return fmt.Errorf("%w [co-signature base64 decode error]", ErrCorruptContainer)
+		}
+		var cs Cosignature
+		copy(cs.TAG[:], f[3])
+		copy(cs.SIG[:], sig)
+		cs.TSS = f[4]
+		id.IO.COSIG = append(id.IO.COSIG, cs)
+	}
+	return nil
+}
+
+// signedBy ...
+func (id *HQ) signedBy(nametag [30]byte) bool {
+	if id.ID.TAG == nametag {
+		return true
+	}
+	for _, cs := range id.IO.COSIG {
+		if cs.TAG == nametag {
+			return true
+		}
+	}
+	return false
+}
+
+// verifySigs validates the primary and all co-signatures over the same message hash, a
+// cryptographically invalid signature always fails, without threshold the primary signer
+// must be trusted [legacy], with threshold k at least k distinct trusted signers are required
+func (id *HQ) verifySigs(path string) error {
+	msg := id.IO.MSG
+	if !id.validateSig() {
+		id.report()
+		id.reportSigFail()
+		return ErrSignatureMismatch
+	}
+	if len(id.IO.COSIG) == 0 && id.IO.Threshold <= 1 {
+		if err := id.trustVerified(path); err != nil {
+			return err
+		}
+		id.IO.Signers = 1
+		return nil
+	}
+	signers := make(map[[30]byte]bool)
+	errPrimary := id.checkTrust(path)
+	id.IO.ReportValid = errPrimary == nil
+	if errPrimary == nil {
+		signers[id.ID.TAG] = true
+	}
+	id.report()
+	for _, cs := range id.IO.COSIG {
+		co := &HQ{ID: id.ID, IO: id.IO, ui: id.ui}
+		co.IO.COSIG, co.IO.FileName, co.IO.ReportTime, co.IO.Trust = nil, "", false, TrustUnknown
+		co.IO.TSS, co.IO.MSG, co.IO.SIG = cs.TSS, msg, cs.SIG
+		if err := co.signerKey(string(cs.TAG[:])); err != nil {
+			if !errors.Is(err, ErrKeyNotFound) {
+				return err
+			}
+			co.ID.TAG = cs.TAG
+			co.ID.OWNER = pad("<unknown signer>")
+			co.report()
+			continue
+		}
+		if !co.validateSig() {
+			co.report()
+			co.reportSigFail()
+			return fmt.Errorf("%w [co-signature %s]", ErrSignatureMismatch, string(cs.TAG[:]))
+		}
+		co.IO.ReportValid = co.checkTrust(path) == nil
+		if co.IO.ReportValid {
+			signers[cs.TAG] = true
+		}
+		co.report()
+	}
+	id.IO.Signers = len(signers)
+	id.IO.ReportValid = errPrimary == nil
+	if id.IO.Threshold <= 1 && errPrimary != nil {
+		return errPrimary
+	}
+	required := max(id.IO.Threshold, 1)
+	id.reportSigners(required)
+	if id.IO.Signers < required {
+		id.IO.ReportValid = false
+		return fmt.Errorf("%w [%d of %d required signers]", ErrThreshold, id.IO.Signers, required)
+	}
+	id.IO.ReportValid = true
+	return nil
+}
+
+// cosign appends a co-signature of the requested identity [--as] to an existing
+// .hqs container [or the current .hqMAP of a directory]
+func (c *Config) cosign(ctx context.Context) (*HQ, error) {
+	filename := c.FileName
+	if isDir(filename) {
+		mapName, err := c.getMap()
+		if err != nil {
+			return nil, err
+		}
+		filename = mapName + _extSignature
+	}
+	if !strings.HasSuffix(filename, _extSignature) {
+		return nil, errors.New("cosign requires an .hqs container or a signed directory [" + filename + "]")
+	}
+	orig := NewHQ(c)
+	orig.IO.FileName = filename
+	container, err := readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err = orig.decodeSig(container); err != nil {
+		return nil, err
+	}
+	if orig.IO.IsExec {
+		return nil, errors.New("cosign of .hqx exec containers is not supported [" + filename + "]")
+	}
+	if orig.IO.MSG, err = getMSGHash(filename[:len(filename)-len(_extSignature)]); err != nil {
+		return nil, err
+	}
+	msg := orig.IO.MSG
+	if !orig.validateSig() {
+		return nil, fmt.Errorf("%w [refuse to cosign %s]", ErrSignatureMismatch, filename)
+	}
+	id := NewHQ(c)
+	id.setPass(c)
+	id.IO.Signify = false
+	id.IO.FileName = filename
+	if err := id.readPublicKey(id.signAs()); err != nil {
+		return nil, err
+	}
+	if orig.signedBy(id.ID.TAG) {
+		return nil, errors.New("container is already signed by " + string(id.ID.TAG[:]) + " [" + filename + "]")
+	}
+	if err := id.prepSign("pending cosign operation [" + filename + "]"); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	id.IO.Start = time.Now()
+	id.IO.TSS = strconv.FormatInt(id.IO.Start.Unix(), 10)
+	id.IO.MSG = msg
+	if err := id.genSig(); err != nil {
+		return nil, err
+	}
+	orig.IO.COSIG = append(orig.IO.COSIG, Cosignature{TAG: id.ID.TAG, TSS: id.IO.TSS, SIG: id.IO.SIG})
+	if container, err = orig.encodeSig(); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filename, container, 0o770); err != nil {
+		return nil, fmt.Errorf("unable to write signature [%s] [%w]", filename, err)
+	}
+	id.report()
+	return orig, nil
+}
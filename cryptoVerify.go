@@ -20,59 +20,62 @@ const (
 // cryptoVerify ...
 func (c *Config) cryptoVerify() bool {
 	id := NewHQ(c)
+	u := id.ui
 	id.ID.OWNER = pad(_testVectorOwner)
 	id.IO.HashPassONE = hashWrap512([]byte(_testVectorPassOne))
 	id.IO.HashPassTWO = hashWrap512([]byte(_testVectorPassTwo))
 	id.IO.TSS = strconv.FormatInt(id.IO.Start.Unix(), 10)
-	out("\nTEST VECTOR: [g]enerate & [u]nlock hq identity! [nameTag = mac integ checksum] and generate signify legacy keypair!")
+	u.out("\nTEST VECTOR: [g]enerate & [u]nlock hq identity! [nameTag = mac integ checksum] and generate signify legacy keypair!")
 	id.IO.Signify = true
 	id.IO.SIGNIFYMSG = []byte(_testVectorMsg)
 	id.IO.Start = time.Now()
-	id.genSphincs()
-	out(yON + "\n[ " + cOFF + "generate" + yON + " ]" + cOFF)
-	id.report()
-	if id.IO.ColorUI {
-		gON, yON, cOFF = _Green, _Yelllow, _Off
-		valid, fail = _Valid, _Fail
+	if err := id.genSphincs(); err != nil {
+		u.errOut(err.Error())
+		return false
 	}
-	signifyState := fail + _space
+	u.out(u.yON + "\n[ " + u.cOFF + "generate" + u.yON + " ]" + u.cOFF)
+	id.report()
+	signifyState := u.fail + _space
 	sig := strings.Split(string(id.IO.SIGNIFYPUB), _linefeedS)
 	if sig[1] == _testVectorSignify {
-		signifyState = valid + _space
+		signifyState = u.valid + _space
 	}
-	ok, tag := false, fail+_space
+	ok, tag := false, u.fail+_space
 	if string(id.ID.TAG[:]) == _testVectorTag {
-		ok, tag = true, valid+_space
+		ok, tag = true, u.valid+_space
 	}
-	out(yON + "HQ Name TAG     : " + tag + bON + _testVectorTag + cOFF)
-	out(yON + "SignifyPubKey   : " + signifyState + bON + sig[1] + cOFF)
-	out("\nTEST VECTOR: [s]ign & [v]erify message via hq sphincs and legacy signify signatures!")
+	u.out(u.yON + "HQ Name TAG     : " + tag + u.bON + _testVectorTag + u.cOFF)
+	u.out(u.yON + "SignifyPubKey   : " + signifyState + u.bON + sig[1] + u.cOFF)
+	u.out("\nTEST VECTOR: [s]ign & [v]erify message via hq sphincs and legacy signify signatures!")
 	id.IO.TSS = strconv.FormatInt(id.IO.Start.Unix(), 10)
 	id.IO.MSG = hashWrap512([]byte(_testVectorMessage))
 	id.IO.FileName = _testVectorMsg
-	out(yON + "\n[ " + cOFF + "sign" + yON + " ]" + cOFF)
+	u.out(u.yON + "\n[ " + u.cOFF + "sign" + u.yON + " ]" + u.cOFF)
 	id.IO.End = time.Time{}
 	id.IO.Start = time.Now()
-	id.genSig()
+	if err := id.genSig(); err != nil {
+		u.errOut(err.Error())
+		return false
+	}
 	id.report()
-	out(yON + "\n[ " + cOFF + "verify" + yON + " ]" + cOFF)
+	u.out(u.yON + "\n[ " + u.cOFF + "verify" + u.yON + " ]" + u.cOFF)
 	id.IO.MSG = hashWrap512([]byte(_testVectorMessage))
 	id.IO.End = time.Time{}
 	id.IO.Start = time.Now()
 	if id.validateSig() {
 		id.IO.ReportValid = true
 		id.report()
-		outPlain(yON + "Status         : " + valid)
+		u.outPlain(u.yON + "Status         : " + u.valid)
 	} else {
 		id.IO.ReportValid = false
 		id.report()
-		outPlain(yON + "Status         : " + fail)
+		u.outPlain(u.yON + "Status         : " + u.fail)
 		ok = false
 	}
 	if ok {
-		out(gON + "\n\nCryptographic hq internal library status is valid!" + cOFF)
+		u.out(u.gON + "\n\nCryptographic hq internal library status is valid!" + u.cOFF)
 		return true
 	}
-	out(aON + "\n\nCryptographic hq internal library validation failed!" + cOFF)
+	u.out(u.aON + "\n\nCryptographic hq internal library validation failed!" + u.cOFF)
 	return false
 }
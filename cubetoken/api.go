@@ -2,6 +2,8 @@
 package cubetoken
 
 import (
+	"io"
+
 	"paepcke.de/signify"
 	"paepcke.de/sphincs"
 )
@@ -10,6 +12,7 @@ import (
 type Config struct {
 	Progress                  bool
 	ForceNoColor              bool
+	Out                       io.Writer // progress [nil: os.Stdout]
 	Memlimit, Parallel, Layer int
 	One, Two, Owner, KeyMac   [64]byte
 }
@@ -1,10 +1,13 @@
 package cubetoken
 
+import "sync"
+
 // generate ...
 func generate(c *Config) SeedToken {
-	// spinup display engine
+	// spinup display engine [per call, Generate may run repeatedly within long-running processes]
+	displayChan, display := make(chan []byte, 15), &sync.WaitGroup{}
 	display.Add(1)
-	go startDisplayEngine(c)
+	go startDisplayEngine(c, displayChan, display)
 
 	// define layer internal function array structure
 	var (
@@ -1,6 +1,7 @@
 package cubetoken
 
 import (
+	"io"
 	"os"
 	"sync"
 	"syscall"
@@ -11,37 +12,39 @@ import (
 //
 
 // outSlice ...
-func outSlice(msg []byte) {
-	os.Stdout.Write(msg)
+func outSlice(w io.Writer, msg []byte) {
+	if w == nil {
+		w = os.Stdout
+	}
+	w.Write(msg)
 }
 
-// global display engine
-var displayChan, display = make(chan []byte, 15), sync.WaitGroup{}
-
 // startDisplayEngine, a non-blocking conditional background output handler
-func startDisplayEngine(c *Config) {
+func startDisplayEngine(c *Config, displayChan chan []byte, display *sync.WaitGroup) {
 	if c.Progress {
 		if getColorUI(c) {
 			go func() {
-				outSlice([]byte(_blue))
+				outSlice(c.Out, []byte(_blue))
 				for b := range displayChan {
-					outSlice(b)
+					outSlice(c.Out, b)
 				}
-				outSlice([]byte(_off))
+				outSlice(c.Out, []byte(_off))
 				display.Done()
 			}()
 			return
 		}
 		go func() {
-			outSlice([]byte(_blue))
+			outSlice(c.Out, []byte(_blue))
 			for b := range displayChan {
-				outSlice(b)
+				outSlice(c.Out, b)
 			}
-			outSlice([]byte(_off))
+			outSlice(c.Out, []byte(_off))
 			display.Done()
 		}()
 		return
 
+	}
+	for range displayChan {
 	}
 	display.Done()
 }
@@ -0,0 +1,187 @@
+package hq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+)
+
+// diffMap verifies the signature of the .hqMAP selected by the time stamp prefix ts
+// and returns its parsed entries
+func (c *Config) diffMap(ts string) (*HQ, map[string]mapEntry, error) {
+	sel := *c
+	sel.TargetTS = ts
+	mapName, err := sel.getMap()
+	if err != nil {
+		return nil, nil, err
+	}
+	id := NewHQ(c)
+	id.IO.DirName = c.FileName
+	id.IO.FileName = mapName + _extSignature
+	if err := id.pinKey(c); err != nil {
+		return nil, nil, err
+	}
+	container, err := readFile(id.IO.FileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = id.decodeSig(container); err != nil {
+		return nil, nil, err
+	}
+	if id.IO.IsExec {
+		return nil, nil, fmt.Errorf("%w [%s]", ErrCorruptContainer, id.IO.FileName)
+	}
+	if id.IO.MSG, err = getMSGHash(mapName); err != nil {
+		return nil, nil, err
+	}
+	if err = id.verifySigs(id.IO.DirName); err != nil {
+		return id, nil, err
+	}
+	data, err := decompressReadFile(mapName)
+	if err != nil {
+		return id, nil, err
+	}
+	m, err := parseMap(data, c.FileName)
+	if err != nil {
+		return id, nil, err
+	}
+	return id, m, nil
+}
+
+// diff compares two signed .hqMAP snapshots of the directory c.FileName, both signatures
+// must validate, a removed and an added file with the same hash are reported as renamed
+func (c *Config) diff(ctx context.Context, ts1, ts2 string) (*Diff, error) {
+	from, prev, err := c.diffMap(ts1)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	to, curr, err := c.diffMap(ts2)
+	if err != nil {
+		return nil, err
+	}
+	if from.IO.FileName == to.IO.FileName {
+		return nil, errors.New("both time stamps select the same .hqMAP [" + from.IO.FileName + "]")
+	}
+	d := &Diff{
+		Dir:     c.FileName,
+		From:    from.IO.FileName[:len(from.IO.FileName)-len(_extSignature)],
+		To:      to.IO.FileName[:len(to.IO.FileName)-len(_extSignature)],
+		FromTSS: from.IO.TSS,
+		ToTSS:   to.IO.TSS,
+	}
+	var added []string
+	for name, e := range curr {
+		p, ok := prev[name]
+		switch {
+		case !ok:
+			added = append(added, name)
+		case p.hash != e.hash || p.chash != e.chash:
+			d.Modified = append(d.Modified, name)
+		case p.hasMeta && e.hasMeta && len(p.meta.drift(e.meta)) != 0:
+			d.Modified = append(d.Modified, name)
+		}
+	}
+	removed := make(map[string][]string) // hash -> removed names
+	for name, p := range prev {
+		if _, ok := curr[name]; !ok {
+			removed[p.hash] = append(removed[p.hash], name)
+		}
+	}
+	for _, names := range removed {
+		slices.Sort(names)
+	}
+	slices.Sort(added)
+	for _, name := range added {
+		h := curr[name].hash
+		if names := removed[h]; len(names) != 0 {
+			d.Renamed = append(d.Renamed, Rename{From: names[0], To: name})
+			removed[h] = names[1:]
+			continue
+		}
+		d.Added = append(d.Added, name)
+	}
+	for _, names := range removed {
+		d.Removed = append(d.Removed, names...)
+	}
+	slices.Sort(d.Removed)
+	slices.Sort(d.Modified)
+	return d, nil
+}
+
+// runDiff [hq diff <dir> <ts1> <ts2>]
+func (c *Config) runDiff(ctx context.Context) error {
+	u := c.ui()
+	d, err := c.diff(ctx, os.Args[3], os.Args[4])
+	if err != nil {
+		if c.JSON {
+			u.out(jsonLine(jsonReport{Type: "diff", Action: "diff", Dir: c.FileName, Error: err.Error(), ErrorCode: jsonErrCode(err)}))
+		}
+		return err
+	}
+	d.report(c, u)
+	return nil
+}
+
+// report ...
+func (d *Diff) report(c *Config, u *ui) {
+	if c.JSON {
+		for _, name := range d.Added {
+			u.out(jsonLine(jsonFile{Type: "file", File: name, Reason: "added"}))
+		}
+		for _, name := range d.Removed {
+			u.out(jsonLine(jsonFile{Type: "file", File: name, Reason: "removed"}))
+		}
+		for _, name := range d.Modified {
+			u.out(jsonLine(jsonFile{Type: "file", File: name, Reason: "modified"}))
+		}
+		for _, r := range d.Renamed {
+			u.out(jsonLine(jsonFile{Type: "file", File: r.To, From: r.From, Reason: "renamed"}))
+		}
+		u.out(jsonLine(jsonReport{
+			Type:         "diff",
+			Action:       "diff",
+			Dir:          d.Dir,
+			From:         d.From,
+			To:           d.To,
+			Valid:        true,
+			FilesNew:     uint64(len(d.Added)),
+			FilesRemoved: uint64(len(d.Removed)),
+			FilesChanged: uint64(len(d.Modified)),
+			FilesRenamed: uint64(len(d.Renamed)),
+		}))
+		return
+	}
+	if c.Silent {
+		return
+	}
+	label := [...]string{_dfrom, _dto, _dadded, _dremoved, _dmodified, _drenamed, _fnew, _fremoved, _fchanged, _frenamed}
+	if u.color {
+		for i := range label {
+			label[i] = _Yelllow + label[i] + _Off
+		}
+		defer u.outPlain(u.cOFF)
+	}
+	u.out("\n" + label[0] + u.bON + d.From + u.cOFF)
+	u.out(label[1] + u.bON + d.To + u.cOFF)
+	for _, name := range d.Added {
+		u.out(label[2] + name)
+	}
+	for _, name := range d.Removed {
+		u.out(label[3] + name)
+	}
+	for _, name := range d.Modified {
+		u.out(label[4] + name)
+	}
+	for _, r := range d.Renamed {
+		u.out(label[5] + r.From + " -> " + r.To)
+	}
+	for i, n := range [...]int{len(d.Added), len(d.Removed), len(d.Modified), len(d.Renamed)} {
+		u.out(label[6+i] + u.bON + strconv.Itoa(n) + u.cOFF)
+	}
+}
@@ -0,0 +1,70 @@
+package hq
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffRenamed(t *testing.T) {
+	ctx := context.Background()
+	c := testConfig(t)
+	c.FileName = t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(c.FileName, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"a", "b", "c", "x1", "x2"} {
+		content := name
+		if name[0] == 'x' {
+			content = "same"
+		}
+		write(name, content)
+	}
+	from, err := SignDir(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(1100 * time.Millisecond) // next map time stamp
+
+	for _, mv := range [][2]string{{"a", "a2"}, {"x1", "y1"}, {"x2", "y2"}} {
+		if err := os.Rename(filepath.Join(c.FileName, mv[0]), filepath.Join(c.FileName, mv[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Remove(filepath.Join(c.FileName, "c")); err != nil {
+		t.Fatal(err)
+	}
+	write("b", "modified")
+	write("e", "e")
+	to, err := SignDir(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := DiffDir(ctx, c, from.TSS, to.TSS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel := func(names []string) []string {
+		for i, name := range names {
+			names[i] = strings.TrimPrefix(name, fixPath(c.FileName))
+		}
+		return names
+	}
+	var renamed []string
+	for _, r := range d.Renamed {
+		renamed = append(renamed, rel([]string{r.From})[0]+">"+rel([]string{r.To})[0])
+	}
+	if !slices.Equal(rel(d.Added), []string{"e"}) ||
+		!slices.Equal(rel(d.Removed), []string{"c"}) ||
+		!slices.Equal(rel(d.Modified), []string{"b"}) ||
+		!slices.Equal(renamed, []string{"a>a2", "x1>y1", "x2>y2"}) {
+		t.Fatalf("diff: added %v, removed %v, modified %v, renamed %v", d.Added, d.Removed, d.Modified, renamed)
+	}
+}
@@ -1,49 +1,92 @@
 package hq
 
 import (
-	"io"
+	"context"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
 // dirSign ...
-func (c *Config) dirSign() bool {
+func (c *Config) dirSign(ctx context.Context) (*HQ, error) {
 	id := NewHQ(c)
+	id.setPass(c)
 	id.IO.DirName = c.FileName
-	id.IO.MapClean = isMapClean()
 	id.IO.TSS = strconv.FormatInt(id.IO.Start.Unix(), 10)
-	id.IO.Silent = c.Silent
-	id.IO.FileName = c.FileName
-	switch {
-	case id.IO.FileName == ".":
-		id.IO.FileName = ".hqMAP." + id.IO.TSS + "." + unix2RFC3339(id.IO.TSS) + _compressedFileExt
-	default:
-		id.IO.FileName = id.IO.FileName + "/" + ".hqMAP." + id.IO.TSS + "." + unix2RFC3339(id.IO.TSS) + _compressedFileExt
-	}
-
-	// defaults
-	var (
-		sepone         = []byte("\n")
-		septwo         = []byte("\n\n")
-		waitWorkerDone sync.WaitGroup
-	)
+	stamp, err := unix2RFC3339(id.IO.TSS)
+	if err != nil {
+		return nil, err
+	}
+	id.IO.FileName = fixPath(c.mapDir()) + ".hqMAP." + id.IO.TSS + "." + stamp + _compressedFileExt
+	root, err := filepath.Abs(id.IO.DirName)
+	if err != nil {
+		return nil, err
+	}
+
+	// defaults, the walker and the hash workers stop once the sign operation fails
+	var waitWorkerDone sync.WaitGroup
+	start := id.IO.Start
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	// setup channel struct
 	type obj struct {
-		filename []byte
-		hash     []byte
-		chash    []byte
-		code     bool
+		seq    uint64 // walk order
+		name   string
+		hash   string
+		chash  string     // code review hash [optional]
+		meta   string     // size|mtime|mode meta line [optional]
+		fail   reasonCode // sign time error [_hashOK: none]
+		reused bool
+		skip   bool // no record [code review hash failed]
+	}
+	type feed struct {
+		seq  uint64
+		name string
+		fail reasonCode // unreadable directory [_hashOK: none]
+	}
+
+	// setup collector result struct
+	type done struct {
+		total uint64
+		fails []failed // sign time errors
+		end   time.Time
+		err   error
+	}
+
+	// ignore rules [.hqignore, --exclude|--include]
+	ign, err := newIgnoreRules(id.IO.DirName, c.Exclude, c.Include)
+	if err != nil {
+		return nil, err
+	}
+
+	// previous signed .hqMAP & stat cache [incremental mode]
+	cache := id.loadSignCache(c)
+
+	// hash chain link to the previous .hqMAP
+	link, err := newMapLink(c.mapDir(), filepath.Base(id.IO.FileName), c.mapPolicy())
+	if err != nil {
+		return nil, err
+	}
+
+	// streaming map output
+	mapOut, err := createCompressFile(id.IO.FileName, _compressedMapLevel, 0o660)
+	if err != nil {
+		return nil, err
 	}
 
 	// setup channel & wait groups
 	waitWorkerDone.Add(id.IO.CPU)
 	chanOut := make(chan obj, 100)
-	chanFeed := make(chan string, 10000)
-	chanCount := make(chan uint64, 1)
-	chanEnd := make(chan time.Time, 1)
+	chanNames := make(chan feed, 10000)
+	chanFeed := make(chan feed, 10000)
+	chanWindow := make(chan struct{}, _mapWindow)
+	chanWalkErr := make(chan error, 1)
+	chanDone := make(chan done, 1)
 
 	// lauch global master control process
 	go func() {
@@ -51,148 +94,211 @@ func (c *Config) dirSign() bool {
 		close(chanOut)
 	}()
 
-	// collect chanOut -> data slice & write as compressed map, report, sign
+	// collect chanOut -> restore walk order, stream records into the compressed map, report, sign
 	go func() {
 		var (
-			data  []byte
-			total uint64
+			total   uint64
+			fails   []failed
+			seen    uint64
+			next    uint64
+			errOut  error
+			record  []byte
+			pending = make(map[uint64]obj)
 		)
-		for t := range chanOut {
-			data = append(data, []byte(t.filename)...)
-			data = append(data, []byte(t.hash)...)
-			if t.code {
-				data = append(data, []byte(t.chash)...)
+		prefix := fixPath(id.IO.DirName) // records are relative to the signed directory
+		_, errOut = mapOut.Write(newMap(root, c.walkOptions(), link))
+		for o := range chanOut {
+			pending[o.seq] = o
+			for {
+				t, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				next++
+				<-chanWindow
+				if t.skip {
+					continue
+				}
+				if errOut == nil {
+					record = appendMapRecord(record[:0], strings.TrimPrefix(t.name, prefix), t.hash, t.chash, t.meta)
+					_, errOut = mapOut.Write(record)
+				}
+				total++
+				if t.fail != _hashOK {
+					fails = append(fails, failed{filename: t.name, reason: t.fail})
+				}
+				if cache.prev == nil {
+					continue
+				}
+				e, ok := cache.prev[t.name]
+				switch {
+				case !ok:
+					id.IO.FilesNew++
+					continue
+				case t.reused:
+					id.IO.FilesReused++
+				case e.hash != t.hash:
+					id.IO.FilesChanged++
+				}
+				seen++
 			}
-			total++
 		}
-		compressWriteFile(id.IO.FileName, data, _compressedMapLevel, 0o660)
-		chanCount <- total
-		close(chanCount)
-		chanEnd <- time.Now()
-		close(chanEnd)
+		id.IO.FilesRemoved = uint64(len(cache.prev)) - seen
+		err := <-chanWalkErr
+		if err == nil {
+			err = errOut
+		}
+		if err == nil {
+			if _, err = mapOut.Write(mapTrailer(ign)); err == nil {
+				err = mapOut.Close()
+			}
+		}
+		if err != nil {
+			mapOut.abort()
+		}
+		chanDone <- done{total: total, fails: fails, end: time.Now(), err: err}
+		close(chanDone)
 	}()
 
-	// start case specific hash worker group
-	switch c.CodeReview {
-	case true:
-		for i := 0; i < id.IO.CPU; i++ {
-			go func() {
-				var chash []byte
-				var code bool
-				for t := range chanFeed {
-					file, _ := os.Open(t)
-					reader, hash := io.Reader(file), blake3New256()
-					for {
-						block := make([]byte, _hashBlockSize)
-						l, _ := reader.Read(block)
-						if l < _hashBlockSize {
-							hash.Write(block)
-							break
-						}
-						hash.Write(block)
-					}
-					file.Close()
-					h := hash.Sum(nil)
-					code, chash = codeReviewHash(t)
-					switch code {
-					case true:
-						if chash == nil {
-							continue
-						}
-						chanOut <- obj{
-							filename: append([]byte(t), sepone...),
-							hash:     append(s2hex(h[:]), sepone...),
-							chash:    append(chash, septwo...),
-							code:     true,
-						}
-					case false:
-						chanOut <- obj{
-							filename: append([]byte(t), sepone...),
-							hash:     append(s2hex(h[:]), septwo...),
-							code:     false,
-						}
-					}
+	// start hash worker group, sign time errors are recorded [meta err] instead of a content hash
+	for i := 0; i < id.IO.CPU; i++ {
+		go func() {
+			for f := range chanFeed {
+				if ctx.Err() != nil {
+					chanOut <- obj{seq: f.seq, skip: true} // drain
+					continue
 				}
-				waitWorkerDone.Done()
-			}()
-		}
-	case false:
-		for i := 0; i < id.IO.CPU; i++ {
-			go func() {
-				for t := range chanFeed {
-					file, _ := os.Open(t)
-					reader, hash := io.Reader(file), blake3New256()
-					for {
-						block := make([]byte, _hashBlockSize)
-						l, _ := reader.Read(block)
-						if l < _hashBlockSize {
-							hash.Write(block)
-							break
-						}
-						hash.Write(block)
+				t := f.name
+				hash, lfi, reason := hashFile(t) // meta before content, a concurrent change never gets a matching meta line
+				if f.fail != _hashOK && reason == _hashOK {
+					reason = f.fail // unreadable directory, its entries are not signed
+				}
+				o := obj{seq: f.seq, name: t, hash: hash, fail: reason}
+				switch {
+				case reason != _hashOK:
+					var m fileMeta
+					if lfi != nil {
+						m = newFileMeta(t, lfi)
 					}
-					file.Close()
-					h := hash.Sum(nil)
-					chanOut <- obj{
-						filename: append([]byte(t), sepone...),
-						hash:     append(s2hex(h[:]), septwo...),
+					m.err = reason.String()
+					o.hash, o.meta = _symlinkBrokenHash, m.encode()
+				case c.CodeReview:
+					code, chash := codeReviewHash(t)
+					if code && chash == nil {
+						chanOut <- obj{seq: f.seq, skip: true}
+						continue
 					}
+					o.chash, o.meta = string(chash), mapMeta(t, lfi)
+				default:
+					o.meta = mapMeta(t, lfi)
 				}
-				waitWorkerDone.Done()
-			}()
-		}
+				chanOut <- o
+			}
+			waitWorkerDone.Done()
+		}()
 	}
 
-	// feeder [fastwalk]
+	// incremental filter, number entries in walk order, reuse unchanged entries of the previous signed .hqMAP
 	go func() {
-		path := id.IO.DirName
-		dirlist, err := os.ReadDir(path)
-		if err != nil {
-			errExit("unable to read directory [" + path + "] [" + err.Error() + "]")
+		var seq uint64
+		for f := range chanNames {
+			chanWindow <- struct{}{} // bound out of order records buffered by the collector
+			e, fi, ok := cache.lookup(f.name)
+			switch ok {
+			case true:
+				chanOut <- obj{
+					seq:    seq,
+					name:   f.name,
+					hash:   e.hash,
+					chash:  e.chash,
+					meta:   mapMeta(f.name, fi),
+					fail:   _hashOK,
+					reused: true,
+				}
+			case false:
+				f.seq = seq
+				chanFeed <- f
+			}
+			seq++
 		}
-		for _, item := range dirlist {
-			name := fixPath(path) + item.Name()
-			inodeType := uint32(item.Type())
-			switch {
-			case inodeType&_modeSymlink != 0:
-				chanFeed <- name // profile symbolic links - but do not [recursive] follow
-			case inodeType&_modeDir != 0:
-				walk(name, chanFeed)
-			case id.IO.MapClean && len(name) > 39 && name[:6] == ".hqMAP":
-				continue
-			default:
-				chanFeed <- name
+		close(chanFeed)
+	}()
+
+	// feeder [sorted, parallel read ahead]
+	go func() {
+		defer close(chanWalkErr)
+		defer close(chanNames)
+		w := newWalker(id.IO.DirName, c.walkOptions(), id.IO.WalkThreads, ign).excludeDir(c.mapDir())
+		err := w.walk(ctx, id.IO.DirName, func(name string, err error) error {
+			f := feed{name: name, fail: _hashOK}
+			if err != nil {
+				f.fail = errReason(err)
 			}
+			chanNames <- f
+			return ctx.Err()
+		})
+		if err == nil {
+			err = ign.err
 		}
-		if id.IO.MapClean {
-			cleanMapFiles(id.IO.DirName)
+		if err != nil {
+			chanWalkErr <- err
 		}
-		close(chanFeed)
 	}()
 
+	// discard stops the workers and removes the unsigned .hqMAP, the previous .hqMAP and
+	// .hqCACHE stay in place [the next verify still finds the last signed map]
+	discard := func(err error) error {
+		cancel()
+		<-chanDone
+		os.Remove(id.IO.FileName)
+		return err
+	}
+
 	// prep sign
 	if !c.MapOnly {
 		// now, everything is busy in the background, time to keep the user busy as well
 		// ask for creds and compute hash cube in parallel (b/c hasher thread can be IO limited)
 		id.IO.ReportValid = false
-		id.readPublicKey("me")
-		id.passEntry("pending hqMAP sign operation [" + id.IO.DirName + "]")
-		id.unlockHQ()
+		if err := id.prepSign("pending hqMAP sign operation [" + id.IO.DirName + "]"); err != nil {
+			return nil, discard(err)
+		}
 	}
 
 	// wait till all threads done, report, sign
-	id.IO.FilesTotal = <-chanCount
+	r := <-chanDone
+	if r.err != nil {
+		return nil, fmt.Errorf("unable to write .hqMAP [%s] [%w]", id.IO.FileName, r.err)
+	}
+	for _, f := range r.fails {
+		if !id.IO.Silent || id.IO.JSON {
+			id.ui.out(id.reportFail(f))
+		}
+	}
+	id.IO.FilesTotal = r.total
+	id.IO.FilesFail = uint64(len(r.fails))
 	id.IO.ReportValid = false
-	id.IO.End = <-chanEnd
+	id.IO.End = r.end
 	id.reportDir()
-	if c.MapOnly {
-		return true
-	}
-	id.IO.MSG = getMSGHash(id.IO.FileName)
-	id.IO.End = time.Time{}
-	id.IO.Start = time.Now()
-	id.genSig()
-	id.report()
-	id.writeSig()
-	return true
+	if cache.prev != nil {
+		id.reportDelta()
+	}
+	if !c.MapOnly {
+		if id.IO.MSG, err = getMSGHash(id.IO.FileName); err != nil {
+			return nil, discard(err)
+		}
+		id.IO.End = time.Time{}
+		id.IO.Start = time.Now()
+		if err = id.genSig(); err != nil {
+			return nil, discard(err)
+		}
+		id.report()
+		if err = id.writeSig(); err != nil {
+			return nil, discard(err)
+		}
+	}
+	if err = cache.write(fixPath(c.mapDir())+_signCache, filepath.Base(id.IO.FileName), c.CodeReview, start); err != nil {
+		return nil, fmt.Errorf("unable to write .hqCACHE [%w]", err)
+	}
+	return id, pruneMaps(c.mapDir(), filepath.Base(id.IO.FileName), c.mapPolicy())
 }
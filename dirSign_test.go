@@ -0,0 +1,209 @@
+package hq
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignDirBadPassphraseKeepsSignedMap(t *testing.T) {
+	ctx := context.Background()
+	c := testConfig(t)
+	c.FileName = t.TempDir()
+	for i, name := range []string{"a", "b/c", "b/d"} {
+		name = filepath.Join(c.FileName, name)
+		if err := os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte{byte(i)}, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := SignDir(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	before, err := listMaps(c.FileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, err := os.ReadFile(filepath.Join(c.FileName, _signCache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(1100 * time.Millisecond) // next map time stamp
+
+	bad := *c
+	bad.PassONE = "wrong-passphrase"
+	if _, err := SignDir(ctx, &bad); err == nil {
+		t.Fatal("sign with a wrong passphrase succeeded")
+	}
+	after, err := listMaps(c.FileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(before, after) {
+		t.Fatalf("failed sign changed the maps [%v -> %v]", before, after)
+	}
+	if b, err := os.ReadFile(filepath.Join(c.FileName, _signCache)); err != nil || string(b) != string(cache) {
+		t.Fatalf("failed sign replaced the previous %s [%v]", _signCache, err)
+	}
+	r, err := VerifyDir(ctx, c)
+	if err != nil {
+		t.Fatalf("previous map no longer verifies: %v", err)
+	}
+	if !r.Valid {
+		t.Fatal("previous map not valid")
+	}
+}
+
+func TestSignDirRecordsUnreadableDirectory(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("directory permissions do not apply to root")
+	}
+	ctx := context.Background()
+	c := testConfig(t)
+	c.FileName = t.TempDir()
+	locked := filepath.Join(c.FileName, "locked")
+	if err := os.MkdirAll(locked, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(locked, "f"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(c.FileName, "g"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(locked, 0o700) })
+	if _, err := SignDir(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	mapName, err := c.getMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := decompressReadFile(mapName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := parseMap(data, c.FileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := signed[locked]; !ok || e.meta.err != _reasonPermission.String() || e.meta.ftype != "dir" {
+		t.Fatalf("unreadable directory not recorded [%v %+v]", ok, e.meta)
+	}
+	var report bytes.Buffer
+	v := *c
+	v.JSON, v.Stdout = true, &report
+	if _, err := VerifyDir(ctx, &v); !errors.Is(err, ErrFilesModified) {
+		t.Fatalf("expected ErrFilesModified, got %v", err)
+	}
+	want := jsonLine(jsonFile{Type: "file", File: locked, Reason: _reasonNotSigned.String(), Expected: _reasonPermission.String()})
+	if !strings.Contains(report.String(), want) || strings.Contains(report.String(), `"reason":"new"`) {
+		t.Fatalf("verify report: %s", report.String())
+	}
+}
+
+func TestSignDirExcludesNestedMapDir(t *testing.T) {
+	ctx := context.Background()
+	c := testConfig(t)
+	c.FileName = t.TempDir()
+	c.MapDir = filepath.Join(c.FileName, "sub", "evidence")
+	if err := os.MkdirAll(c.MapDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(c.FileName, "sub", "f"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 2 {
+		if i > 0 {
+			time.Sleep(1100 * time.Millisecond) // next map time stamp
+		}
+		s, err := SignDir(ctx, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.FilesTotal != 1 {
+			t.Fatalf("sign %d: map and cache files recorded [%d entries]", i, s.FilesTotal)
+		}
+	}
+	r, err := VerifyDir(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.FilesNew != 0 || r.FilesTotal != 1 {
+		t.Fatalf("verify: %d new, %d total", r.FilesNew, r.FilesTotal)
+	}
+}
+
+func TestSignDirEscapesNames(t *testing.T) {
+	ctx := context.Background()
+	c := testConfig(t)
+	c.FileName = t.TempDir()
+	inject := "x\n" + strings.Repeat("0", 64) + "\n\n" + _mapRules + "\n" + `#rule "" **`
+	for _, name := range []string{inject, `back\slash\n`, "y"} {
+		if err := os.WriteFile(filepath.Join(c.FileName, name), []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err := SignDir(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.FilesTotal != 3 {
+		t.Fatalf("signed %d entries", s.FilesTotal)
+	}
+	if err := os.WriteFile(filepath.Join(c.FileName, "y"), []byte("modified"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r, err := VerifyDir(ctx, c)
+	if !errors.Is(err, ErrFilesModified) || r.FilesTotal != 3 || r.FilesFail != 1 || r.FilesNew != 0 {
+		t.Fatalf("verify: %v [%+v]", err, r)
+	}
+}
+
+func TestSignDirReusesOnlyOwnMap(t *testing.T) {
+	ctx := context.Background()
+	c := testConfig(t)
+	c.FileName = t.TempDir()
+	for _, name := range []string{"a", "b"} {
+		if err := os.WriteFile(filepath.Join(c.FileName, name), []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(_signCacheRacy + 100*time.Millisecond) // cacheable [not racy] entries
+	if _, err := SignDir(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(1100 * time.Millisecond) // next map time stamp
+
+	// second identity within the same keystore, now me
+	other := *c
+	other.Owner = "other@hq.test"
+	if _, err := Generate(ctx, &other); err != nil {
+		t.Fatal(err)
+	}
+	s, err := SignDir(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.FilesReused != 0 {
+		t.Fatalf("reused %d hashes from a map signed by another key", s.FilesReused)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	if s, err = SignDir(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	if s.FilesReused != 2 {
+		t.Fatalf("reused %d hashes from the own map", s.FilesReused)
+	}
+}
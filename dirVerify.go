@@ -1,78 +1,104 @@
 package hq
 
 import (
-	"io"
-	"os"
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"path/filepath"
 	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // dirVerify
-func (c *Config) dirVerify() bool {
+func (c *Config) dirVerify(ctx context.Context) (*HQ, error) {
 	id := NewHQ(c)
 	id.IO.DirName = c.FileName
 	id.IO.ReportValid = true
-	id.IO.FileName = c.getMap()
-	id.IO.FileName += _extSignature
-	id.IO.Silent = c.Silent
-	var waitTotals sync.WaitGroup
+	if err := id.pinKey(c); err != nil {
+		return nil, err
+	}
+	mapName, err := c.getMap()
+	if err != nil {
+		return nil, err
+	}
+	id.IO.FileName = mapName + _extSignature
+	var (
+		waitTotals sync.WaitGroup
+		errMap     error
+	)
 	waitTotals.Go(func() {
-		id.IO.FilesTotal, id.IO.FilesFail, id.IO.FilesNew = verifyMap(id)
+		id.IO.FilesTotal, id.IO.FilesFail, id.IO.FilesNew, id.IO.FilesSkipped, errMap = verifyMap(ctx, id)
 	})
-	id.parseSig(c)
+	if err = id.parseSig(c); err != nil {
+		waitTotals.Wait()
+		return nil, err
+	}
 	id.IO.ReportValid = false
-	sigState := id.validateSig()
 	waitTotals.Wait()
 	id.reportDir()
 	id.IO.Start = time.Now()
-	if sigState {
-		id.IO.ReportValid = true
-		id.report()
-		return id.IO.FilesFail == 0
+	if err = id.verifySigs(id.IO.DirName); err != nil {
+		return id, err
 	}
-	id.report()
-	if id.IO.ColorUI {
-		stat, fail = _Stat, _Fail
+	switch {
+	case errMap != nil:
+		return id, errMap
+	case id.IO.FilesFail != 0:
+		return id, fmt.Errorf("%w [%d files]", ErrFilesModified, id.IO.FilesFail)
 	}
-	out(stat + "SIGNATURE VALIDATION: " + fail)
-	return id.IO.FilesFail == 0
+	return id, nil
 }
 
-// verigyMap ...
-func verifyMap(id *HQ) (filesTotal, filesFail, filesNew uint64) {
+// verifyMap ...
+func verifyMap(ctx context.Context, id *HQ) (filesTotal, filesFail, filesNew, filesSkipped uint64, err error) {
 	// global locks
 	var waitDisplay, waitWorker sync.WaitGroup
 
+	// signed map & its recorded ignore rules [never the current .hqignore files]
+	r, err := decompressReadFile(id.IO.FileName[:len(id.IO.FileName)-4])
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	ign, err := decodeIgnoreRules(id.IO.DirName, mapRules(r))
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	opt, err := mapWalk(r)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	sel, err := newSelectRules(id.IO.DirName, id.IO.Only)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+
 	// setup channel structure
 	type feed struct {
 		filename string
 		hash     string
 		chash    string
 		code     bool
-	}
-	type failed struct {
-		filename string
-		reason   int    // enum err
-		exp      string // file hash expected
-		calc     string // file hash calculated
-		cexp     string // code hash expected
-		ccalc    string // code hash calculated
+		meta     fileMeta
+		hasMeta  bool
 	}
 
 	// setup global communication channel
 	chanFeed := make(chan feed, 10000)
 	chanFail := make(chan failed, 500)
-	chanFound := make(chan string, 500)
-	chanCurrent := make(chan []string, 1)
 	chanNewFiles := make(chan uint64, 1)
 	chanTotal := make(chan uint64, 1)
+	chanErr := make(chan error, 2)
+	var skipped atomic.Uint64
+	mapTS := mapUnix(id.IO.FileName)
 
 	// lauch global master control process
 	waitWorker.Add(id.IO.CPU)
 	go func() {
 		waitWorker.Wait()
-		close(chanFound)
 		close(chanFail)
 	}()
 
@@ -80,7 +106,9 @@ func verifyMap(id *HQ) (filesTotal, filesFail, filesNew uint64) {
 	chanDisplay := make(chan string, 10)
 	waitDisplay.Go(func() {
 		for t := range chanDisplay {
-			out(t)
+			if !id.IO.Silent || id.IO.JSON {
+				id.ui.out(t)
+			}
 		}
 	})
 
@@ -88,83 +116,78 @@ func verifyMap(id *HQ) (filesTotal, filesFail, filesNew uint64) {
 	for i := 0; i < id.IO.CPU; i++ {
 		go func() {
 			for t := range chanFeed {
-				file, err := os.Open(t.filename)
-				if err != nil {
-					if t.hash == _symlinkBrokenHash {
-						f, err := os.Lstat(t.filename)
-						switch {
-						case err != nil: // is removed
-							chanFail <- failed{filename: t.filename, reason: 1}
-						case uint32(f.Mode())&_modeSymlink != 0: // no change, still a broken symlink
-							chanFound <- t.filename
-						}
-						continue
-					}
-					_, err = os.Stat(t.filename)
-					switch err.Error() {
-					case "file does not exist":
-						chanFail <- failed{filename: t.filename, reason: 1}
-					case "permission denied":
-						chanFail <- failed{filename: t.filename, reason: 2}
-					default:
-						chanFail <- failed{filename: t.filename, reason: 0}
-
-					}
+				var reported bool
+				fail := func(f failed) {
+					f.filename, f.more = t.filename, reported
+					reported = true
+					chanFail <- f
+				}
+				switch {
+				case t.meta.err == _reasonRemoved.String():
+					continue // removed while signing, a current entry is new
+				case t.meta.err != "":
+					fail(failed{reason: _reasonNotSigned, exp: t.meta.err})
 					continue
 				}
-				chanFound <- t.filename
-				reader, hash := io.Reader(file), blake3New256()
-				for {
-					block := make([]byte, _hashBlockSize)
-					l, _ := reader.Read(block)
-					if l < _hashBlockSize {
-						hash.Write(block)
-						break
+				if t.hasMeta {
+					if cur, ok := statMeta(t.filename); ok {
+						for _, d := range t.meta.drift(cur) {
+							fail(failed{reason: d.reason, exp: d.exp, calc: d.found})
+						}
+						if id.IO.Quick && !reported && t.meta.quickMatch(cur, mapTS) {
+							skipped.Add(1)
+							continue
+						}
 					}
-					hash.Write(block)
 				}
-				file.Close()
-				h := hash.Sum(nil)
-				fHash := string(s2hex(h[:]))
+				fHash, _, reason := hashFile(t.filename)
+				if reason != _hashOK {
+					fail(failed{reason: reason})
+					continue
+				}
 				if fHash == t.hash {
+					if id.IO.Quick && !reported {
+						if id.IO.JSON {
+							chanDisplay <- jsonLine(jsonFile{Type: "file", File: t.filename, Reason: _jsonConfirmed})
+							continue
+						}
+						chanDisplay <- id.ui.fhashed + t.filename + id.ui.cOFF
+					}
 					continue
 				}
 				switch t.code {
 				case true:
 					valid, cHash := codeReviewHash(t.filename)
 					if !valid {
-						chanFail <- failed{
-							filename: t.filename,
-							reason:   6,
-							exp:      t.hash,
-							calc:     fHash,
-							cexp:     t.chash,
-							ccalc:    string(cHash),
-						}
+						fail(failed{
+							reason: _reasonCodeFailed,
+							exp:    t.hash,
+							calc:   fHash,
+							cexp:   t.chash,
+							ccalc:  string(cHash),
+						})
 						continue
 					}
 					if string(cHash) != t.chash {
-						chanFail <- failed{
-							filename: t.filename,
-							reason:   5,
-							exp:      t.hash,
-							calc:     fHash,
-							cexp:     t.chash,
-							ccalc:    string(cHash),
-						}
+						fail(failed{
+							reason: _reasonCodeChanged,
+							exp:    t.hash,
+							calc:   fHash,
+							cexp:   t.chash,
+							ccalc:  string(cHash),
+						})
 						continue
 					}
-					chanFail <- failed{
-						filename: t.filename,
-						reason:   4,
-						exp:      t.hash,
-						calc:     fHash,
-						cexp:     t.chash,
-						ccalc:    string(cHash),
-					}
+					fail(failed{
+						reason: _reasonCodeUnchanged,
+						exp:    t.hash,
+						calc:   fHash,
+						cexp:   t.chash,
+						ccalc:  string(cHash),
+					})
 					continue
 				case false:
-					chanFail <- failed{filename: t.filename, reason: 3, exp: t.hash, calc: fHash}
+					fail(failed{reason: _reasonModified, exp: t.hash, calc: fHash})
 					continue
 				}
 			}
@@ -172,117 +195,188 @@ func verifyMap(id *HQ) (filesTotal, filesFail, filesNew uint64) {
 		}()
 	}
 
-	// feeder [fast hot path, small branch tree]
+	// feeder
 	go func() {
-		r := decompressReadFile(id.IO.FileName[:len(id.IO.FileName)-4])
-		sizeMap := len(r)
 		var total uint64
-		var hash string
-		filename, chash, code := make([]byte, 0, 256), make([]byte, 0, 64), false
-		for i := 0; i < sizeMap; i++ {
-			if r[i] == _linefeed {
-				if len(filename) == 0 {
-					continue
-				}
-				if i+1+64 < sizeMap && r[i+1] != _linefeed { // hash found
-					hash = string(r[i+1 : i+65])
-					i += 64 + 1
-					if i+1+64 < sizeMap && r[i+1] != _linefeed { // optional chash detected
-						code = true
-						chash = r[i+1 : i+65]
-						i += 64 + 1
-					}
-				} else {
-					chanDisplay <- "[error] input map is corrupt [last valid: " + string(filename) + "]"
-					break
-				}
-				chanFeed <- feed{
-					filename: string(filename),
-					hash:     hash,
-					chash:    string(chash),
-					code:     code,
-				}
-				filename, chash, code = nil, nil, false
-				total++
-				continue
+		err := walkMapPath(r, id.IO.DirName, func(name string, e mapEntry) error {
+			if total&1023 == 0 && ctx.Err() != nil {
+				return ctx.Err()
 			}
-			filename = append(filename, r[i])
+			if !sel.selected(name) {
+				return nil
+			}
+			chanFeed <- feed{
+				filename: name,
+				hash:     e.hash,
+				chash:    e.chash,
+				code:     e.chash != "",
+				meta:     e.meta,
+				hasMeta:  e.hasMeta,
+			}
+			total++
+			return nil
+		})
+		if err == nil && total == 0 && sel != nil {
+			err = errors.New("no .hqMAP entry matches the --only selection")
+		}
+		if err != nil {
+			chanErr <- err
 		}
 		close(chanFeed)
 		chanTotal <- total
 		close(chanTotal)
 	}()
 
-	// collect current filesystem state
-	go func() {
-		chanCurrent <- recursiveFileList(id.IO.DirName, 2)
-		close(chanCurrent)
-	}()
-
-	// collect chanFound, diff against current state
+	// new files, merge the current filesystem state with the signed entries [both in walk order]
 	go func() {
-		if id.IO.ColorUI {
-			fnew, cOFF = _Fnew, _Off
-		}
-		var foundlist []string
-		for found := range chanFound {
-			foundlist = append(foundlist, found)
-		}
 		var totalNew uint64
-		current := <-chanCurrent
-		for _, found := range current {
-			hit := slices.Contains(foundlist, found)
-			if hit {
-				continue
+		w := newWalker(id.IO.DirName, opt, id.IO.WalkThreads, ign).excludeDir(filepath.Dir(id.IO.FileName))
+		err := newFiles(ctx, w, id.IO.DirName, signedNames(r, id.IO.DirName), func(name string) {
+			if !sel.selected(name) {
+				return
 			}
 			totalNew++
-			chanDisplay <- fnew + found + cOFF
+			if id.IO.JSON {
+				chanDisplay <- jsonLine(jsonFile{Type: "file", File: name, Reason: _jsonNew})
+				return
+			}
+			chanDisplay <- id.ui.fnew + name + id.ui.cOFF
+		})
+		if err != nil {
+			chanErr <- err
 		}
 		chanNewFiles <- totalNew
 	}()
 
 	// collect chanFail
-	if id.IO.ColorUI {
-		aON, bON, cON, gON, cOFF = _Red, _Blue, _Cyan, _Green, _Off
-		file, errc, exp, calc, cexp, ccalc = _File, _Errc, _Exp, _Calc, _Cexp, _Ccalc
-	}
 	for t := range chanFail {
-		var r string
-		filesFail++
-		switch {
-		case len(t.filename) > 120:
-			r = file + bON + "\n" + t.filename + cOFF + "\n"
-		default:
-			r = file + bON + t.filename + cOFF + "\n"
-		}
-		e := r + errc + aON
-		switch t.reason {
-		case 0:
-			chanDisplay <- e + _errFileAccess + cOFF + "\n"
-		case 1:
-			chanDisplay <- e + _errFileNotExist + cOFF + "\n"
-		case 2:
-			chanDisplay <- e + _errFilePermission + cOFF + "\n"
-		case 3:
-			e = e + _errFileChecksum + cOFF
-			chanDisplay <- e + "\n" + exp + cON + t.exp + cOFF + "\n" + calc + cON + t.calc + cOFF + "\n"
-		case 4:
-			x := errc + gON + _errChashOK + cOFF + "\n"
-			e = e + _errFileChecksum + cOFF
-			chanDisplay <- e + "\n" + exp + cON + t.exp + cOFF + "\n" + calc + cON + t.calc + cOFF + "\n" + x + cexp + cON + t.cexp + cOFF + "\n" + ccalc + cON + t.ccalc + cOFF + "\n"
-		case 5:
-			x := errc + aON + _errChashFail + cOFF + "\n"
-			e = e + _errFileChecksum + cOFF
-			chanDisplay <- e + "\n" + exp + cON + t.exp + cOFF + "\n" + calc + cON + t.calc + cOFF + "\n" + x + cexp + cON + t.cexp + cOFF + "\n" + ccalc + cON + t.ccalc + cOFF + "\n"
-		case 6:
-			x := errc + aON + _errChashUnable + cOFF + "\n"
-			e = e + _errFileChecksum + cOFF
-			chanDisplay <- e + "\n" + exp + cON + t.exp + cOFF + "\n" + calc + cON + t.calc + cOFF + "\n" + x + cexp + cON + t.cexp + cOFF + "\n" + ccalc + cON + t.ccalc + cOFF + "\n"
+		if !t.more {
+			filesFail++
 		}
+		chanDisplay <- id.reportFail(t)
 	}
 	filesNew = <-chanNewFiles
 	close(chanDisplay)
 	waitDisplay.Wait()
 	filesTotal = <-chanTotal
-	return filesTotal, filesFail, filesNew
+	close(chanErr)
+	var errs []error
+	for e := range chanErr {
+		errs = append(errs, e)
+	}
+	return filesTotal, filesFail, filesNew, skipped.Load(), errors.Join(errs...)
+}
+
+// errStop ends a map walk early
+var errStop = errors.New("stop")
+
+// signedNames returns the walk path of every entry present at sign time in walk order, v2 maps
+// [#root] are streamed as recorded, older maps [parallel hash order] are sorted first
+func signedNames(data []byte, dir string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		_, ordered := mapRoot(data)
+		var names []string
+		_ = walkMapPath(data, dir, func(name string, e mapEntry) error { // corrupt records: reported by the feeder
+			switch {
+			case e.meta.err == _reasonRemoved.String():
+				return nil // removed while signing, a current entry is new
+			case !ordered:
+				names = append(names, name)
+				return nil
+			case !yield(name):
+				return errStop
+			}
+			return nil
+		})
+		slices.SortFunc(names, cmpWalk)
+		for _, name := range names {
+			if !yield(name) {
+				return
+			}
+		}
+	}
+}
+
+// newFiles walks dir and calls fn for every current entry not within signed [sorted merge, both in walk order]
+func newFiles(ctx context.Context, w *walker, dir string, signed iter.Seq[string], fn func(name string)) error {
+	next, stop := iter.Pull(signed)
+	defer stop()
+	s, ok := next()
+	return w.walk(ctx, dir, func(name string, _ error) error {
+		for ok && cmpWalk(s, name) < 0 {
+			s, ok = next()
+		}
+		if ok && s == name {
+			s, ok = next()
+			return nil
+		}
+		fn(name)
+		return nil
+	})
+}
+
+// failed ...
+type failed struct {
+	filename string
+	reason   reasonCode
+	exp      string // file hash expected
+	calc     string // file hash calculated
+	cexp     string // code hash expected
+	ccalc    string // code hash calculated
+	more     bool   // additional failure of an already reported file
+}
+
+// reportFail returns the [text|json] report of a failed file
+func (id *HQ) reportFail(t failed) string {
+	if id.IO.JSON {
+		return jsonLine(jsonFile{
+			Type:         "file",
+			File:         t.filename,
+			Reason:       t.reason.String(),
+			Expected:     t.exp,
+			Found:        t.calc,
+			CodeExpected: t.cexp,
+			CodeFound:    t.ccalc,
+		})
+	}
+	u := id.ui
+	file, errc, exp, calc, cexp, ccalc := u.file, u.errc, u.exp, u.calc, u.cexp, u.ccalc
+	aON, bON, cON, gON, cOFF := u.aON, u.bON, u.cON, u.gON, u.cOFF
+	var r string
+	switch {
+	case len(t.filename) > 120:
+		r = file + bON + "\n" + t.filename + cOFF + "\n"
+	default:
+		r = file + bON + t.filename + cOFF + "\n"
+	}
+	e := r + errc + aON
+	switch t.reason {
+	case _reasonUnreadable:
+		return e + _errFileAccess + cOFF + "\n"
+	case _reasonRemoved:
+		return e + _errFileNotExist + cOFF + "\n"
+	case _reasonPermission:
+		return e + _errFilePermission + cOFF + "\n"
+	case _reasonModified:
+		e = e + _errFileChecksum + cOFF
+		return e + "\n" + exp + cON + t.exp + cOFF + "\n" + calc + cON + t.calc + cOFF + "\n"
+	case _reasonCodeUnchanged:
+		x := errc + gON + _errChashOK + cOFF + "\n"
+		e = e + _errFileChecksum + cOFF
+		return e + "\n" + exp + cON + t.exp + cOFF + "\n" + calc + cON + t.calc + cOFF + "\n" + x + cexp + cON + t.cexp + cOFF + "\n" + ccalc + cON + t.ccalc + cOFF + "\n"
+	case _reasonCodeChanged:
+		x := errc + aON + _errChashFail + cOFF + "\n"
+		e = e + _errFileChecksum + cOFF
+		return e + "\n" + exp + cON + t.exp + cOFF + "\n" + calc + cON + t.calc + cOFF + "\n" + x + cexp + cON + t.cexp + cOFF + "\n" + ccalc + cON + t.ccalc + cOFF + "\n"
+	case _reasonCodeFailed:
+		x := errc + aON + _errChashUnable + cOFF + "\n"
+		e = e + _errFileChecksum + cOFF
+		return e + "\n" + exp + cON + t.exp + cOFF + "\n" + calc + cON + t.calc + cOFF + "\n" + x + cexp + cON + t.cexp + cOFF + "\n" + ccalc + cON + t.ccalc + cOFF + "\n"
+	case _reasonType, _reasonSymlink, _reasonMode, _reasonOwner:
+		e = e + _errDrift[t.reason-_reasonType] + cOFF
+		return e + "\n" + exp + cON + t.exp + cOFF + "\n" + calc + cON + t.calc + cOFF + "\n"
+	case _reasonNotSigned:
+		return e + _errFileNotSigned + " [" + strings.ToUpper(t.exp) + "]" + cOFF + "\n"
+	}
+	return e + _errFileAccess + cOFF + "\n"
 }
@@ -0,0 +1,151 @@
+package hq
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// testTree creates files in dirs directories below root [names sort differently per path and per component]
+func testTree(t testing.TB, root string, dirs, files int) {
+	t.Helper()
+	for d := range dirs {
+		dir := filepath.Join(root, "d"+strconv.Itoa(d))
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(dir+".txt", nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		for f := range files {
+			if err := os.WriteFile(filepath.Join(dir, "f"+strconv.Itoa(f)), nil, 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestNewFilesMerge(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	testTree(t, root, 12, 12)
+	w := newWalker(root, walkOptions{}, 0, nil)
+	current, err := w.list(ctx, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.IsSortedFunc(current, cmpWalk) {
+		t.Fatal("walker output is not in cmpWalk order")
+	}
+	var signed, want, got []string
+	for i, name := range current {
+		if i%7 == 0 {
+			want = append(want, name)
+			continue
+		}
+		signed = append(signed, name)
+	}
+	signed = append(signed, fixPath(root)+"d1/removed", fixPath(root)+"zz")
+	slices.SortFunc(signed, cmpWalk)
+	err = newFiles(ctx, w, root, slices.Values(signed), func(name string) { got = append(got, name) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("new files\n got %v\nwant %v", got, want)
+	}
+}
+
+func BenchmarkNewFiles(b *testing.B) {
+	for _, n := range []int{10, 100} {
+		b.Run(strconv.Itoa(n*n)+"files", func(b *testing.B) {
+			ctx := context.Background()
+			root := b.TempDir()
+			testTree(b, root, n, n)
+			w := newWalker(root, walkOptions{}, 0, nil)
+			current, err := w.list(ctx, root)
+			if err != nil {
+				b.Fatal(err)
+			}
+			var signed []string // 1% new
+			for i, name := range current {
+				if i%100 != 0 {
+					signed = append(signed, name)
+				}
+			}
+			for b.Loop() {
+				var found int
+				err := newFiles(ctx, w, root, slices.Values(signed), func(string) { found++ })
+				if err != nil || found != len(current)-len(signed) {
+					b.Fatalf("new files: %d of %d [%v]", found, len(current)-len(signed), err)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyDirMapOutRelocated(t *testing.T) {
+	ctx := context.Background()
+	c := testConfig(t)
+	c.FileName = filepath.Join(t.TempDir(), "tree")
+	testTree(t, c.FileName, 2, 2)
+	c.MapDir = t.TempDir()
+	s, err := SignDir(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(c.FileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name()[0] == '.' {
+			t.Fatalf("evidence file %s written into the signed tree", e.Name())
+		}
+	}
+	moved := filepath.Join(t.TempDir(), "moved")
+	if err := os.Rename(c.FileName, moved); err != nil {
+		t.Fatal(err)
+	}
+	c.FileName = moved
+	r, err := VerifyDir(ctx, c)
+	if err != nil || !r.Valid || r.FilesTotal != s.FilesTotal {
+		t.Fatalf("relocated tree: %v [%+v]", err, r)
+	}
+	if err := os.WriteFile(filepath.Join(moved, "d0", "f0"), []byte("modified"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if r, err = VerifyDir(ctx, c); !errors.Is(err, ErrFilesModified) || r.FilesFail != 1 {
+		t.Fatalf("modified relocated tree: %v [%+v]", err, r)
+	}
+}
+
+func TestVerifyPaths(t *testing.T) {
+	ctx := context.Background()
+	c := testConfig(t)
+	c.FileName = t.TempDir()
+	testTree(t, c.FileName, 3, 2)
+	if _, err := SignDir(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string]string{"d1/f0": "modified", "d1/new": "", "d2/new": ""} {
+		if err := os.WriteFile(filepath.Join(c.FileName, name), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, err := VerifyPaths(ctx, c, []string{"d0", "d2/f*"})
+	if err != nil || !r.Valid || r.FilesTotal != 4 || r.FilesNew != 0 {
+		t.Fatalf("unchanged selection: %v [%+v]", err, r)
+	}
+	r, err = VerifyPaths(ctx, c, []string{"d1/*"})
+	if !errors.Is(err, ErrFilesModified) || r.FilesTotal != 2 || r.FilesFail != 1 || r.FilesNew != 1 {
+		t.Fatalf("changed selection: %v [%+v]", err, r)
+	}
+	if _, err = VerifyPaths(ctx, c, []string{"missing"}); err == nil {
+		t.Fatal("selection without a .hqMAP entry verified")
+	}
+}
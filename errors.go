@@ -0,0 +1,33 @@
+package hq
+
+import "errors"
+
+// sentinel errors, test via errors.Is()
+var (
+	// ErrKeyNotFound the requested public key is not present in the keystore
+	ErrKeyNotFound = errors.New("public key not found")
+	// ErrTagChecksum the public key does not match its NameTAG checksum
+	ErrTagChecksum = errors.New("key integrity problem, tag checksum missmatch")
+	// ErrSignatureMismatch the signature does not validate against message and key
+	ErrSignatureMismatch = errors.New("signature validation failed")
+	// ErrUntrustedKey the signature is valid, but the signer is not trusted [for this path]
+	ErrUntrustedKey = errors.New("untrusted signer")
+	// ErrCorruptContainer the .hqs/.hqx container can not be parsed
+	ErrCorruptContainer = errors.New("defective .hqs/.hqx file or pipe container")
+	// ErrCorruptKey a [public|unlocked] key file can not be decoded
+	ErrCorruptKey = errors.New("defective key file")
+	// ErrPassphrase the passphrases are invalid or do not unlock the requested identity
+	ErrPassphrase = errors.New("passphrases do not match, unable to unlock")
+	// ErrPassphraseRequired no passphrases are provided and no terminal is available
+	ErrPassphraseRequired = errors.New("passphrases required, but no terminal available")
+	// ErrMapNotFound no matching .hqMAP found in the target directory
+	ErrMapNotFound = errors.New("unable to find a .hqMAP")
+	// ErrFilesModified the directory state does not match the signed .hqMAP
+	ErrFilesModified = errors.New("files [modified|removed|unreadable] since sign operation")
+	// ErrThreshold not enough distinct valid and trusted signers [multi-signature container]
+	ErrThreshold = errors.New("signer threshold not met")
+	// ErrChainBroken the .hqMAP history has gaps, forks or replaced snapshots [verify --chain]
+	ErrChainBroken = errors.New(".hqMAP chain broken")
+	// ErrPolicy the operation is disabled by build-time security policy
+	ErrPolicy = errors.New("operation disabled by security policy")
+)
@@ -1,15 +1,16 @@
 package hq
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 )
 
 // runExec ...
-func (id *HQ) runExec() bool {
+func (id *HQ) runExec() error {
 	s := matchShebang(id.IO.TokenExec)
 	if s.interp == "disabled" {
-		errExit("executable interpreter [" + s.name + "] for [" + s.ext + "] is explicitly disabled by security policy")
+		return fmt.Errorf("%w [executable interpreter %s for %s is explicitly disabled]", ErrPolicy, s.name, s.ext)
 	}
 	var (
 		err error
@@ -19,19 +20,23 @@ func (id *HQ) runExec() bool {
 	case id.IO.PlainTextScript:
 		f, err = os.Open(id.IO.FileName)
 		if err != nil {
-			errExit("unable to open file [" + id.IO.FileName + "] [" + err.Error() + "]")
+			return fmt.Errorf("unable to open file [%s] [%w]", id.IO.FileName, err)
 		}
 		f.Close()
 	default:
-		script := string(decompressZstd(id.IO.SCRIPT))
-		f, err = os.CreateTemp("/var/tmp", "scratchpad")
+		script, err := decompressZstd(id.IO.SCRIPT)
 		if err != nil {
-			errExit("unable to create shell temp scratch file")
+			return err
 		}
-		if _, err := f.Write([]byte(script)); err != nil {
-			errExit("unable to write to shell temp scratch file")
+		f, err = os.CreateTemp("/var/tmp", "scratchpad")
+		if err != nil {
+			return fmt.Errorf("unable to create shell temp scratch file [%w]", err)
 		}
 		defer os.Remove(f.Name())
+		if _, err := f.Write(script); err != nil {
+			f.Close()
+			return fmt.Errorf("unable to write to shell temp scratch file [%w]", err)
+		}
 	}
 	f.Close()
 	p := getArgs()
@@ -41,23 +46,20 @@ func (id *HQ) runExec() bool {
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
 	if err := cmd.Start(); err != nil {
-		return false
-	}
-	if err = cmd.Wait(); err != nil {
-		return false
+		return fmt.Errorf("unable to start [%s] [%w]", s.interp, err)
 	}
-	return true
+	return cmd.Wait()
 }
 
 // runExecPlain ...
-func (c *Config) runExecPlain() bool {
+func (c *Config) runExecPlain() error {
 	id := &HQ{
-		ID{},
-		IO{
+		IO: IO{
 			TokenExec:       "POSIX=",
 			PlainTextScript: true,
 			FileName:        c.FileName,
 		},
+		ui: c.ui(),
 	}
 	return id.runExec()
 }
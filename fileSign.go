@@ -0,0 +1,84 @@
+package hq
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// fileSign ...
+func (c *Config) fileSign(ctx context.Context) (*HQ, error) {
+	id := NewHQ(c)
+	id.setPass(c)
+	id.IO.TSS = strconv.FormatInt(id.IO.Start.Unix(), 10)
+	id.IO.FileName = c.FileName
+	id.IO.ScriptExtL = c.ScriptExtL
+	type msg struct {
+		hash [HashSize]byte
+		err  error
+	}
+	chanHash := make(chan msg, 1)
+	switch c.IsExec {
+	case true:
+		id.IO.IsExec = true
+		id.IO.TokenExec = c.TokenExec
+		script, err := readFile(id.IO.FileName)
+		if err != nil {
+			return nil, err
+		}
+		id.IO.SCRIPT = compressZstd(script, _compressedScriptLevel)
+		close(chanHash)
+	default:
+		go func() {
+			h, err := getMSGHash(id.IO.FileName)
+			chanHash <- msg{hash: h, err: err}
+			close(chanHash)
+		}()
+	}
+	if err := id.readPublicKey(id.signAs()); err != nil {
+		return nil, err
+	}
+	if err := id.passEntry("pending " + c.Target + " sign operation [" + id.IO.FileName + "]"); err != nil {
+		return nil, err
+	}
+	if h, ok := <-chanHash; ok {
+		if h.err != nil {
+			return nil, h.err
+		}
+		id.IO.MSG = h.hash
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	id.IO.Start = time.Now()
+	if err := id.unlockHQ(); err != nil {
+		return nil, err
+	}
+	if err := id.genSig(); err != nil {
+		return nil, err
+	}
+	if err := id.writeSig(); err != nil {
+		return nil, err
+	}
+	id.report()
+	return id, nil
+}
+
+// signature ...
+func (id *HQ) signature() (*Signature, error) {
+	container, err := id.encodeSig()
+	if err != nil {
+		return nil, err
+	}
+	return &Signature{
+		ID:           id.ID,
+		FileName:     id.IO.FileName,
+		TSS:          id.IO.TSS,
+		Container:    container,
+		FilesTotal:   id.IO.FilesTotal,
+		FilesNew:     id.IO.FilesNew,
+		FilesChanged: id.IO.FilesChanged,
+		FilesRemoved: id.IO.FilesRemoved,
+		FilesReused:  id.IO.FilesReused,
+	}, nil
+}
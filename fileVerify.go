@@ -0,0 +1,59 @@
+package hq
+
+import "context"
+
+// fileVerify ...
+func (c *Config) fileVerify(ctx context.Context) (*HQ, error) {
+	id := NewHQ(c)
+	id.IO.FileName = c.FileName
+	id.IO.IsExec = c.IsExec
+	id.IO.ReportValid = false
+	if err := id.pinKey(c); err != nil {
+		return nil, err
+	}
+	if err := id.parseSig(c); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return id, id.verifySigs(id.IO.FileName[:len(id.IO.FileName)-4])
+}
+
+// execVerify verifies an hqx container, executes or displays the payload
+func (c *Config) execVerify(ctx context.Context) error {
+	id, err := c.fileVerify(ctx)
+	if err != nil {
+		return err
+	}
+	if c.RunExec {
+		return id.runExec()
+	}
+	script, err := decompressZstd(id.IO.SCRIPT)
+	if err != nil {
+		return err
+	}
+	id.ui.out(string(script))
+	return nil
+}
+
+// result ...
+func (id *HQ) result() *Result {
+	r := &Result{
+		ID:         id.ID,
+		FileName:   id.IO.FileName,
+		TSS:        id.IO.TSS,
+		Valid:      id.IO.ReportValid,
+		Trust:      id.IO.Trust,
+		Signers:    id.IO.Signers,
+		FilesTotal: id.IO.FilesTotal,
+		FilesFail:  id.IO.FilesFail,
+		FilesNew:   id.IO.FilesNew,
+		// quick verify
+		FilesSkipped: id.IO.FilesSkipped,
+	}
+	if id.IO.IsExec {
+		r.Script, _ = decompressZstd(id.IO.SCRIPT)
+	}
+	return r
+}
@@ -1,12 +1,17 @@
 package hq
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
 )
 
-const _hex = "0123456789abcdef"
+// const
+const (
+	_hex    = "0123456789abcdef"
+	_maxTSS = 19 // decimal digits of max int64
+)
 
 func setByte64(in []byte) [64]byte {
 	if len(in) != 64 {
@@ -66,20 +71,32 @@ func s2hex(s []byte) []byte {
 	return t
 }
 
-func unix2RFC3339(in string) string {
-	ts, err := strconv.ParseInt(in, 10, 0)
+func unix2RFC3339(in string) (string, error) {
+	ts, err := parseTSS(in)
 	if err != nil {
-		errExit("TSS time stamp corrupted - parse error")
+		return "", err
 	}
-	return strings.ReplaceAll(time.Unix(ts, 0).Format(time.RFC3339), ":", ".")
+	return strings.ReplaceAll(time.Unix(ts, 0).Format(time.RFC3339), ":", "."), nil
 }
 
-func unix2RFC850(in string) string {
-	ts, err := strconv.ParseInt(in, 10, 0)
+func unix2RFC850(in string) (string, error) {
+	ts, err := parseTSS(in)
 	if err != nil {
-		errExit("TSS time stamp corrupted - parse error")
+		return "", err
+	}
+	return time.Unix(ts, 0).Format(time.RFC850), nil
+}
+
+// parseTSS parses a TSS time stamp [unix seconds, decimal digits only, fits int64]
+func parseTSS(in string) (int64, error) {
+	if len(in) == 0 || len(in) > _maxTSS || strings.Trim(in, "0123456789") != "" {
+		return 0, fmt.Errorf("%w [TSS time stamp corrupted - parse error]", ErrCorruptContainer)
+	}
+	ts, err := strconv.ParseInt(in, 10, 64)
+	if err != nil || ts < 0 {
+		return 0, fmt.Errorf("%w [TSS time stamp corrupted - parse error]", ErrCorruptContainer)
 	}
-	return time.Unix(ts, 0).Format(time.RFC850)
+	return ts, nil
 }
 
 type shebang struct {
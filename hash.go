@@ -3,10 +3,14 @@ package hq
 
 // import
 import (
+	"bytes"
 	"crypto/sha256"
 	"crypto/sha512"
+	"errors"
+	"fmt"
 	"hash"
 	"io"
+	"io/fs"
 	"os"
 
 	// waiting for upstream fix for hash.Hash interface
@@ -22,25 +26,87 @@ const (
 
 // message -> sphincs msg input hash
 // this way we can sign extream large messages really fast, with minimal memory use, even on embedded systems
-func getMSGHash(filename string) [HashSize]byte {
+func getMSGHash(filename string) ([HashSize]byte, error) {
 	file, err := os.Open(filename)
 	if err != nil {
-		errExit("unable to read file [" + filename + "]")
+		return [HashSize]byte{}, fmt.Errorf("unable to read file [%s] [%w]", filename, err)
 	}
-	reader := io.Reader(file)
+	defer file.Close()
+	msg, err := getMSGHashReader(file)
+	if err != nil {
+		return [HashSize]byte{}, fmt.Errorf("unable to read file [%s] [%w]", filename, err)
+	}
+	return msg, nil
+}
+
+// getMSGHashReader is getMSGHash for any io.Reader [pipe|network|memory]
+func getMSGHashReader(reader io.Reader) ([HashSize]byte, error) {
 	h := blake3New512()
-	var l int
+	if err := hashBlocks(h, reader); err != nil {
+		return [HashSize]byte{}, err
+	}
+	return setByte64(h.Sum(nil)), nil
+}
+
+// hashBlocks feeds reader in fixed _hashBlockSize blocks into h, the final
+// [short] block is written zero padded [compatible with all existing signatures]
+func hashBlocks(h hash.Hash, reader io.Reader) error {
 	for {
 		block := make([]byte, _hashBlockSize)
-		l, _ = reader.Read(block)
-		if l < _hashBlockSize {
+		_, err := io.ReadFull(reader, block)
+		switch {
+		case err == nil:
+			h.Write(block)
+		case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
 			h.Write(block)
-			break
+			return nil
+		default:
+			return err
 		}
-		h.Write(block)
 	}
-	file.Close()
-	return setByte64(h.Sum(nil))
+}
+
+// _hashOK is the hashFile reason of a hashed entry
+const _hashOK reasonCode = -1
+
+// hashFile returns the content hash of the walked entry name [blake3] and its lstat entry,
+// reason is _hashOK or the failure [_jsonReason: removed|permission|unreadable], non regular
+// files [after following a symbolic link: broken links, directories, devices] hash as empty content
+func hashFile(name string) (hash string, lfi fs.FileInfo, reason reasonCode) {
+	lfi, err := os.Lstat(name)
+	if err != nil {
+		return "", nil, errReason(err)
+	}
+	fi := lfi
+	if lfi.Mode()&fs.ModeSymlink != 0 {
+		if fi, err = os.Stat(name); err != nil {
+			fi = lfi
+		}
+	}
+	h, r := blake3New256(), io.Reader(bytes.NewReader(nil))
+	if fi.Mode().IsRegular() {
+		file, err := os.Open(name)
+		if err != nil {
+			return "", lfi, errReason(err)
+		}
+		defer file.Close()
+		r = file
+	}
+	if err := hashBlocks(h, r); err != nil {
+		return "", lfi, errReason(err)
+	}
+	return string(s2hex(h.Sum(nil))), lfi, _hashOK
+}
+
+// errReason maps a file access error to its reason [_jsonReason: removed|permission|unreadable]
+func errReason(err error) reasonCode {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return _reasonRemoved
+	case errors.Is(err, fs.ErrPermission):
+		return _reasonPermission
+	}
+	return _reasonUnreadable
 }
 
 // blake3New256 wrapper
@@ -0,0 +1,407 @@
+package hq
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// .hqMAP layout [zstd compressed]
+//
+//	v1: filename\nhash\n[chash\n]\n
+//	v2: #hqMAP 2\n[#root "dir"\n][#walk ..\n][#prev ..\n][#pruned ..\n]\n header record
+//	    filename\nhash\n[chash\n][@meta\n]\n records [sorted, depth first]
+//	    #hqMAP rules\n#rule "base" pattern\n[..]\n trailer record [optional, see ignore.go]
+//
+//	#root "<dir|archive>" [filenames relative to root, backslash and newline escaped: \\, \n]
+//	#walk [follow] [one-fs] [max-depth=<n>] [no-special]
+//	#prev <map> <map hash> <signature hash|->
+//	#pruned <map> <map hash>
+//	@type=<file|symlink|..> [link=<path escaped target>] [size=<bytes> mtime=<unix ns> mode=<octal>] [uid=<n> gid=<n>] [err=<reason>]
+
+// const
+const (
+	_mapHeader     = "#hqMAP 2"
+	_mapRules      = "#hqMAP rules"
+	_mapRoot       = "#root "
+	_mapWindow     = 1 << 16 // max out of order records buffered while signing [memory bound]
+	_mapMetaPrefix = "@"
+)
+
+// fileMeta ...
+type fileMeta struct {
+	ftype    string // entry type ["": unknown, v2 map without type]
+	link     string // symbolic link target
+	size     int64
+	mtime    int64
+	mode     uint32
+	uid      uint32
+	gid      uint32
+	err      string // sign time error [_jsonReason, the content is not signed]
+	hasStat  bool   // size, mtime and mode are valid
+	hasOwner bool   // uid and gid are valid
+}
+
+// metaDrift ...
+type metaDrift struct {
+	reason reasonCode
+	exp    string
+	found  string
+}
+
+// mapEntry ...
+type mapEntry struct {
+	hash    string
+	chash   string
+	meta    fileMeta
+	hasMeta bool
+}
+
+// fileType ...
+func fileType(m fs.FileMode) string {
+	switch m.Type() {
+	case 0:
+		return "file"
+	case fs.ModeDir:
+		return "dir"
+	case fs.ModeSymlink:
+		return "symlink"
+	case fs.ModeNamedPipe:
+		return "pipe"
+	case fs.ModeSocket:
+		return "socket"
+	case fs.ModeDevice:
+		return "device"
+	case fs.ModeDevice | fs.ModeCharDevice:
+		return "chardev"
+	}
+	return "other"
+}
+
+// unixMode returns the posix permission bits [incl. setuid|setgid|sticky]
+func unixMode(m fs.FileMode) uint32 {
+	mode := uint32(m.Perm())
+	if m&fs.ModeSetuid != 0 {
+		mode |= 0o4000
+	}
+	if m&fs.ModeSetgid != 0 {
+		mode |= 0o2000
+	}
+	if m&fs.ModeSticky != 0 {
+		mode |= 0o1000
+	}
+	return mode
+}
+
+// newFileMeta collects the meta data of name, lfi is its [lstat] entry
+func newFileMeta(name string, lfi fs.FileInfo) fileMeta {
+	m := fileMeta{ftype: fileType(lfi.Mode())}
+	fi := lfi
+	if lfi.Mode()&fs.ModeSymlink != 0 {
+		var err error
+		m.link, _ = os.Readlink(name)
+		if fi, err = os.Stat(name); err != nil {
+			return m // broken symlink
+		}
+	}
+	m.size, m.mtime, m.mode, m.hasStat = fi.Size(), fi.ModTime().UnixNano(), unixMode(fi.Mode()), true
+	m.uid, m.gid, m.hasOwner = statOwner(fi)
+	return m
+}
+
+// statMeta returns the current meta data of name
+func statMeta(name string) (fileMeta, bool) {
+	lfi, err := os.Lstat(name)
+	if err != nil {
+		return fileMeta{}, false
+	}
+	return newFileMeta(name, lfi), true
+}
+
+// mapMeta encodes the meta line of name, lfi is its [lstat] entry
+func mapMeta(name string, lfi fs.FileInfo) string {
+	if lfi == nil {
+		return ""
+	}
+	return newFileMeta(name, lfi).encode()
+}
+
+// encode ...
+func (m fileMeta) encode() string {
+	if m.ftype == "" {
+		return _mapMetaPrefix + "err=" + m.err // removed while signing, no meta data
+	}
+	s := _mapMetaPrefix + "type=" + m.ftype
+	if m.ftype == "symlink" {
+		s += " link=" + url.PathEscape(m.link)
+	}
+	if m.hasStat {
+		s += " size=" + strconv.FormatInt(m.size, 10) +
+			" mtime=" + strconv.FormatInt(m.mtime, 10) +
+			" mode=" + strconv.FormatUint(uint64(m.mode), 8)
+	}
+	if m.hasOwner {
+		s += " uid=" + strconv.FormatUint(uint64(m.uid), 10) +
+			" gid=" + strconv.FormatUint(uint64(m.gid), 10)
+	}
+	if m.err != "" {
+		s += " err=" + m.err
+	}
+	return s
+}
+
+// parseMeta ...
+func parseMeta(line string) (fileMeta, error) {
+	var m fileMeta
+	var seen int
+	for field := range strings.FieldsSeq(line[len(_mapMetaPrefix):]) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return m, fmt.Errorf("meta field [%s]", field)
+		}
+		var (
+			n   uint64
+			err error
+		)
+		switch key {
+		case "type":
+			m.ftype = value
+		case "link":
+			m.link, err = url.PathUnescape(value)
+		case "err":
+			m.err = value
+		case "size":
+			m.size, err = strconv.ParseInt(value, 10, 64)
+			seen |= 1
+		case "mtime":
+			m.mtime, err = strconv.ParseInt(value, 10, 64)
+			seen |= 2
+		case "mode":
+			n, err = strconv.ParseUint(value, 8, 32)
+			m.mode = uint32(n)
+			seen |= 4
+		case "uid":
+			n, err = strconv.ParseUint(value, 10, 32)
+			m.uid = uint32(n)
+			seen |= 8
+		case "gid":
+			n, err = strconv.ParseUint(value, 10, 32)
+			m.gid = uint32(n)
+			seen |= 16
+		}
+		if err != nil {
+			return m, fmt.Errorf("meta field [%s]", field)
+		}
+	}
+	m.hasStat, m.hasOwner = seen&7 == 7, seen&24 == 24
+	return m, nil
+}
+
+// quickMatch reports if size, mtime and mode of cur match m, m must be older than the map
+// itself [unix seconds] by at least the racy window, recently changed files are always hashed
+func (m fileMeta) quickMatch(cur fileMeta, mapUnix int64) bool {
+	if !m.hasStat || !cur.hasStat || m.mtime >= time.Unix(mapUnix, 0).Add(-_signCacheRacy).UnixNano() {
+		return false
+	}
+	return (m.ftype == "" || m.ftype == cur.ftype) && m.link == cur.link && m.size == cur.size && m.mtime == cur.mtime && m.mode == cur.mode
+}
+
+// drift returns every meta data change of cur against m [content changes are detected by hash]
+func (m fileMeta) drift(cur fileMeta) []metaDrift {
+	var d []metaDrift
+	if m.ftype != "" && m.ftype != cur.ftype {
+		return append(d, metaDrift{reason: _reasonType, exp: m.ftype, found: cur.ftype})
+	}
+	if m.ftype == "symlink" && m.link != cur.link {
+		d = append(d, metaDrift{reason: _reasonSymlink, exp: m.link, found: cur.link})
+	}
+	if m.hasStat && cur.hasStat && m.mode != cur.mode {
+		d = append(d, metaDrift{
+			reason: _reasonMode,
+			exp:    strconv.FormatUint(uint64(m.mode), 8),
+			found:  strconv.FormatUint(uint64(cur.mode), 8),
+		})
+	}
+	if m.hasOwner && cur.hasOwner && (m.uid != cur.uid || m.gid != cur.gid) {
+		d = append(d, metaDrift{
+			reason: _reasonOwner,
+			exp:    strconv.FormatUint(uint64(m.uid), 10) + ":" + strconv.FormatUint(uint64(m.gid), 10),
+			found:  strconv.FormatUint(uint64(cur.uid), 10) + ":" + strconv.FormatUint(uint64(cur.gid), 10),
+		})
+	}
+	return d
+}
+
+// mapUnix returns the sign time stamp encoded in the .hqMAP filename [0: unknown]
+func mapUnix(filename string) int64 {
+	name := filepath.Base(filename)
+	if len(name) < 17 || name[:7] != ".hqMAP." {
+		return 0
+	}
+	ts, err := strconv.ParseInt(name[7:17], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return ts
+}
+
+// record name escaping [#root maps], a name never spans lines
+var (
+	_nameEscape   = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	_nameUnescape = strings.NewReplacer(`\\`, `\`, `\n`, "\n")
+)
+
+// appendMapRecord ...
+func appendMapRecord(data []byte, name, hash, chash, meta string) []byte {
+	data = append(data, _nameEscape.Replace(name)...)
+	data = append(data, _linefeed)
+	data = append(data, hash...)
+	data = append(data, _linefeed)
+	if chash != "" {
+		data = append(data, chash...)
+		data = append(data, _linefeed)
+	}
+	if meta != "" {
+		data = append(data, meta...)
+		data = append(data, _linefeed)
+	}
+	return append(data, _linefeed)
+}
+
+// newMap returns the v2 .hqMAP header record of the signed directory dir
+func newMap(dir string, opt walkOptions, link mapLink) []byte {
+	b := []byte(_mapHeader + _linefeedS + _mapRoot + strconv.Quote(dir) + _linefeedS + opt.encode())
+	b = append(b, link.encode()...)
+	return append(b, _linefeed)
+}
+
+// mapTrailer returns the v2 .hqMAP trailer record [effective ignore rules]
+func mapTrailer(ign *ignoreRules) []byte {
+	rules := ign.encode()
+	if len(rules) == 0 {
+		return nil
+	}
+	b := []byte(_mapRules + _linefeedS)
+	b = append(b, rules...)
+	return append(b, _linefeed)
+}
+
+// isMapRecord reports if the record lines f are a v2 header|trailer record
+func isMapRecord(f []string) bool {
+	if f[0] != _mapHeader && f[0] != _mapRules {
+		return false
+	}
+	for _, line := range f[1:] {
+		switch {
+		case strings.HasPrefix(line, _ignoreRecord), strings.HasPrefix(line, _mapRoot):
+		case strings.HasPrefix(line, _chainPrev), strings.HasPrefix(line, _chainPruned), strings.HasPrefix(line, _mapWalk):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// mapRoot returns the signed directory recorded in the header of a decompressed v2 .hqMAP,
+// ok is false if the records hold walk paths [v1, early v2]
+func mapRoot(data []byte) (root string, ok bool) {
+	record, _, _ := bytes.Cut(data, []byte("\n\n"))
+	f := strings.Split(string(record), _linefeedS)
+	if f[0] != _mapHeader || !isMapRecord(f) {
+		return "", false
+	}
+	for _, line := range f[1:] {
+		if strings.HasPrefix(line, _mapRoot) {
+			root, err := strconv.Unquote(line[len(_mapRoot):])
+			return root, err == nil
+		}
+	}
+	return "", false
+}
+
+// mapRules returns the recorded ignore rule lines of a decompressed v2 .hqMAP [header|trailer]
+func mapRules(data []byte) []string {
+	var rules []string
+	record, _, _ := bytes.Cut(data, []byte("\n\n"))
+	if f := strings.Split(string(record), _linefeedS); isMapRecord(f) {
+		rules = append(rules, f[1:]...)
+	}
+	if i := bytes.LastIndex(data, []byte("\n\n"+_mapRules+_linefeedS)); i >= 0 {
+		record, _, _ = bytes.Cut(data[i+2:], []byte("\n\n"))
+		if f := strings.Split(string(record), _linefeedS); isMapRecord(f) {
+			rules = append(rules, f[1:]...)
+		}
+	}
+	return rules
+}
+
+// walkMap calls fn for every record of a decompressed [v1|v2] .hqMAP in order
+func walkMap(data []byte, fn func(name string, e mapEntry) error) error {
+	_, escaped := mapRoot(data)
+	for record := range bytes.SplitSeq(data, []byte("\n\n")) {
+		if len(record) == 0 {
+			continue
+		}
+		f := strings.Split(string(record), _linefeedS)
+		if isMapRecord(f) {
+			continue
+		}
+		if len(f) < 2 || len(f[1]) != 64 {
+			return fmt.Errorf("%w [input map is corrupt, record: %s]", ErrCorruptContainer, f[0])
+		}
+		e := mapEntry{hash: f[1]}
+		for i, line := range f[2:] {
+			switch {
+			case strings.HasPrefix(line, _mapMetaPrefix) && !e.hasMeta:
+				m, err := parseMeta(line)
+				if err != nil {
+					return fmt.Errorf("%w [input map is corrupt, record: %s] [%w]", ErrCorruptContainer, f[0], err)
+				}
+				e.meta, e.hasMeta = m, true
+			case i == 0 && len(line) == 64:
+				e.chash = line
+			default:
+				return fmt.Errorf("%w [input map is corrupt, record: %s]", ErrCorruptContainer, f[0])
+			}
+		}
+		name := f[0]
+		if escaped {
+			name = _nameUnescape.Replace(name)
+		}
+		if err := fn(name, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkMapPath calls fn for every record of a decompressed .hqMAP of dir in order, name is
+// the walk path [dir prefixed] of the record
+func walkMapPath(data []byte, dir string, fn func(name string, e mapEntry) error) error {
+	if _, ok := mapRoot(data); !ok {
+		return walkMap(data, fn)
+	}
+	prefix := fixPath(dir)
+	return walkMap(data, func(name string, e mapEntry) error {
+		return fn(prefix+name, e)
+	})
+}
+
+// parseMap parses a decompressed .hqMAP of dir into a walk path indexed map
+func parseMap(data []byte, dir string) (map[string]mapEntry, error) {
+	m := make(map[string]mapEntry)
+	err := walkMapPath(data, dir, func(name string, e mapEntry) error {
+		m[name] = e
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,313 @@
+package hq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+)
+
+// generate ...
+func (c *Config) generate(ctx context.Context) (*HQ, error) {
+	id := NewHQ(c)
+	id.setPass(c)
+	id.IO.SetMe = true
+	switch c.Owner {
+	case "":
+		owner, err := id.ui.getOwner()
+		if err != nil {
+			return nil, err
+		}
+		id.ID.OWNER = owner
+	default:
+		if err := validateOwner(c.Owner); err != nil {
+			return nil, err
+		}
+		id.ID.OWNER = pad(c.Owner)
+	}
+	if err := id.passEntry("create hq identity"); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	id.IO.Start = time.Now()
+	if err := id.genSphincs(); err != nil {
+		return nil, err
+	}
+	if err := id.writePublicKey(); err != nil {
+		return nil, err
+	}
+	id.report()
+	return id, nil
+}
+
+// unlock ...
+func (c *Config) unlock(ctx context.Context) error {
+	if !_allowUnlockViaEnv {
+		return fmt.Errorf("%w [store unlocked key operations]", ErrPolicy)
+	}
+	id := NewHQ(c)
+	id.setPass(c)
+	if err := id.readPublicKey(id.signAs()); err != nil {
+		return err
+	}
+	if err := id.passEntry("pending unlock operation"); err != nil {
+		return err
+	}
+	if id.validateKey() {
+		id.report()
+		return nil
+	}
+	if err := id.wipeUnlockedKey(); err != nil {
+		return fmt.Errorf("unable to [write|delete] key to ~/hq/.unlocked [%w]", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	id.IO.Start = time.Now()
+	if err := id.unlockHQ(); err != nil {
+		return err
+	}
+	if err := id.genSig(); err != nil {
+		return err
+	}
+	if err := id.writeUnlockedKey(); err != nil {
+		return err
+	}
+	id.report()
+	return nil
+}
+
+// lock ...
+func (c *Config) lock(_ context.Context) error {
+	if !_allowUnlockViaEnv {
+		return fmt.Errorf("%w [store unlocked key operations]", ErrPolicy)
+	}
+	id := NewHQ(c)
+	if err := id.readPublicKey(id.signAs()); err != nil {
+		return err
+	}
+	id.report()
+	id.IO.Start = time.Now()
+	return id.wipeUnlockedKey()
+}
+
+// legacyPass ...
+func (c *Config) legacyPass(ctx context.Context) error {
+	id := NewHQ(c)
+	id.setPass(c)
+	if err := id.pwdTarget(c); err != nil {
+		return err
+	}
+	if err := id.prepSign("legacy password [generation|reproduction]"); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	id.IO.Start = time.Now()
+	if err := id.genSig(); err != nil {
+		return err
+	}
+	id.report()
+	id.IO.Start = time.Now()
+	id.reportPwd(c)
+	id.IO.End = time.Time{}
+	id.IO.ReportID = false
+	id.IO.ReportTime = true
+	id.report()
+	return nil
+}
+
+// identities returns all valid public keys within the keystore, sorted by NameTAG,
+// unreadable or corrupt key files are skipped [one error per skipped entry]
+func (c *Config) identities() (ids []*HQ, skipped []error, err error) {
+	keystore, err := NewHQ(c).keyStore()
+	if err != nil {
+		return nil, nil, err
+	}
+	dir, err := readDir(keystore)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, e := range dir {
+		if len(e.Name()) != len(ID{}.TAG) || !e.Type().IsRegular() {
+			continue
+		}
+		id := NewHQ(c)
+		if err := id.readPublicKey(e.Name()); err != nil {
+			skipped = append(skipped, err)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, skipped, nil
+}
+
+// meTag returns the NameTAG the keystore me link points to [empty if unset]
+func (c *Config) meTag() string {
+	keystore, err := NewHQ(c).keyStore()
+	if err != nil {
+		return ""
+	}
+	link, err := os.Readlink(keystore + _me)
+	if err != nil || len(link) < len(ID{}.TAG) {
+		return ""
+	}
+	return link[len(link)-len(ID{}.TAG):]
+}
+
+// useID points the me link to nametag
+func (c *Config) useID(nametag string) (*HQ, error) {
+	id := NewHQ(c)
+	if err := id.readPublicKey(nametag); err != nil {
+		return nil, err
+	}
+	keystore, err := id.keyStore()
+	if err != nil {
+		return nil, err
+	}
+	return id, setMe(keystore, keystore+string(id.ID.TAG[:]))
+}
+
+// removeID deletes the public key, the unlocked key, revocation and trust entries for nametag
+func (c *Config) removeID(nametag string) error {
+	id := NewHQ(c)
+	id.IO.Silent = true
+	if err := id.readPublicKey(nametag); err != nil {
+		return err
+	}
+	keystore, err := id.keyStore()
+	if err != nil {
+		return err
+	}
+	if err := id.readUnlockedKey(); err != nil {
+		return err
+	}
+	if id.IO.UnlockedKey {
+		if err := id.wipeUnlockedKey(); err != nil {
+			return err
+		}
+	}
+	db, err := id.readTrustDB()
+	if err != nil {
+		return err
+	}
+	if db.exists {
+		db.set(string(id.ID.TAG[:]), TrustUnknown, 0)
+		if err := id.writeTrustDB(db); err != nil {
+			return err
+		}
+	}
+	tag := string(id.ID.TAG[:])
+	if c.meTag() == tag {
+		if err := os.Remove(keystore + _me); err != nil {
+			return fmt.Errorf("unable to remove me tag symbolic link [%s] [%w]", keystore+_me, err)
+		}
+	}
+	for _, filename := range []string{keystore + tag + _signifyPubExt, keystore + tag + _extRevoked, keystore + tag} {
+		if err := os.Remove(filename); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("unable to remove [%s] [%w]", filename, err)
+		}
+	}
+	return nil
+}
+
+// status returns the identity state flags [me|unlocked|revoked|trust level]
+func (id *HQ) status(me string) ([]string, error) {
+	var flags []string
+	tag := string(id.ID.TAG[:])
+	if tag == me {
+		flags = append(flags, _me)
+	}
+	if err := id.readUnlockedKey(); err != nil {
+		return nil, err
+	}
+	if id.IO.UnlockedKey {
+		flags = append(flags, "unlocked")
+	}
+	if _, cert, err := id.readRevocation(tag); err != nil || cert != nil {
+		flags = append(flags, "revoked")
+	}
+	db, err := id.readTrustDB()
+	if err != nil {
+		return nil, err
+	}
+	if l := db.level(tag); l != TrustUnknown {
+		flags = append(flags, l.String())
+	}
+	return flags, nil
+}
+
+// runID manages the keystore identities [hq id list|use <nametag>|show <opt:nametag>|rm <nametag>]
+func (c *Config) runID() error {
+	args, cmd, nametag := os.Args[2:], "list", ""
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+	if len(args) > 1 {
+		nametag = args[1]
+	}
+	switch {
+	case cmd == "list" || cmd == "ls":
+		ids, skipped, err := c.identities()
+		if err != nil {
+			return err
+		}
+		u, me := c.ui(), c.meTag()
+		for _, err := range skipped {
+			u.errOut(err.Error())
+		}
+		for _, id := range ids {
+			line := string(id.ID.TAG[:]) + _space + unpad(id.ID.OWNER)
+			flags, err := id.status(me)
+			if err != nil {
+				u.errOut(line + " [" + err.Error() + "]")
+				continue
+			}
+			if len(flags) > 0 {
+				line += " [" + strings.Join(flags, ",") + "]"
+			}
+			u.out(line)
+		}
+		return nil
+	case cmd == "show":
+		if nametag == "" {
+			nametag = NewHQ(c).signAs()
+		}
+		id := NewHQ(c)
+		if err := id.readPublicKey(nametag); err != nil {
+			return err
+		}
+		flags, err := id.status(c.meTag())
+		if err != nil {
+			return err
+		}
+		keystore, err := id.keyStore()
+		if err != nil {
+			return err
+		}
+		id.IO.FileName = keystore + string(id.ID.TAG[:])
+		id.IO.ReportTime = false
+		id.report()
+		id.ui.out(id.ui.stat + strings.Join(flags, ","))
+		return nil
+	case nametag == "":
+	case cmd == "use":
+		id, err := c.useID(nametag)
+		if err != nil {
+			return err
+		}
+		id.IO.ReportTime = false
+		id.IO.ReportValid = true
+		id.report()
+		return nil
+	case cmd == "rm":
+		return c.removeID(nametag)
+	}
+	return errors.New("usage: hq id <list|use <nametag>|show <opt:nametag>|rm <nametag>>")
+}
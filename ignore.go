@@ -0,0 +1,255 @@
+package hq
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// .hqignore [gitignore style subset]
+//
+//	# comment, blank lines are ignored, \# and \! escape the first character
+//	!pattern   re-include [last matching rule wins]
+//	pattern/   match directories only
+//	a/b, /a    patterns with a slash are anchored to the directory of the .hqignore
+//	*.log      patterns without a slash match the name at any depth
+//	**         matches any number of directories [a/**/b, **/b, a/**]
+//
+// excluded directories are never walked, their content can not be re-included,
+// --exclude|--include rules apply to the signed directory and take precedence
+
+// const
+const (
+	_ignoreFile   = ".hqignore"
+	_ignoreRecord = "#rule "
+)
+
+// ignoreRule ...
+type ignoreRule struct {
+	base     string   // directory of the .hqignore [relative, "": root]
+	raw      string   // rule as written
+	segs     []string // pattern segments
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// ignoreRules ...
+type ignoreRules struct {
+	prefix string       // walk path prefix of the signed directory [fixPath]
+	load   bool         // read nested .hqignore files while walking [sign]
+	rules  []ignoreRule // .hqignore rules [in walk order]
+	flags  []ignoreRule // --exclude|--include rules
+	err    error        // first rule parser error
+}
+
+// newIgnoreRule parses a single rule line, ok is false for blank and comment lines
+func newIgnoreRule(base, line string) (ignoreRule, bool, error) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || line[0] == '#' {
+		return ignoreRule{}, false, nil
+	}
+	r := ignoreRule{base: base, raw: line}
+	switch {
+	case line[0] == '!':
+		r.negate, line = true, line[1:]
+	case line[0] == '\\':
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly, line = true, strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		r.anchored, line = true, strings.TrimLeft(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false, fmt.Errorf("invalid ignore rule [%s]", r.raw)
+	}
+	r.segs = strings.Split(line, "/")
+	for _, seg := range r.segs {
+		if _, err := path.Match(seg, ""); err != nil {
+			return ignoreRule{}, false, fmt.Errorf("invalid ignore rule [%s] [%w]", r.raw, err)
+		}
+	}
+	return r, true, nil
+}
+
+// newIgnoreRules returns the sign rule set for dir, nested .hqignore files are loaded while walking
+func newIgnoreRules(dir string, exclude, include []string) (*ignoreRules, error) {
+	ign := &ignoreRules{prefix: fixPath(dir), load: true}
+	for _, p := range exclude {
+		if err := ign.addFlag(p); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range include {
+		if err := ign.addFlag("!" + strings.TrimPrefix(p, "!")); err != nil {
+			return nil, err
+		}
+	}
+	return ign, nil
+}
+
+// newSelectRules returns the verify --only rule set of dir [.hqignore syntax], a matching
+// directory selects its content, paths may be given relative to dir or as walk paths
+func newSelectRules(dir string, only []string) (*ignoreRules, error) {
+	if len(only) == 0 {
+		return nil, nil
+	}
+	sel := &ignoreRules{prefix: fixPath(dir)}
+	for _, p := range only {
+		p = strings.TrimPrefix(strings.TrimPrefix(p, sel.prefix), "./")
+		if err := sel.addFlag(p); err != nil {
+			return nil, err
+		}
+	}
+	return sel, nil
+}
+
+// selected reports if the walk path name or one of its parent directories matches [nil: all]
+func (ign *ignoreRules) selected(name string) bool {
+	return ign == nil || ign.skipPath(name)
+}
+
+// skipPath reports if the walk path name or one of its parent directories is excluded
+func (ign *ignoreRules) skipPath(name string) bool {
+	if ign == nil {
+		return false
+	}
+	rel := strings.TrimPrefix(name, ign.prefix)
+	for i, isDir := len(rel), false; i > 0; i, isDir = strings.LastIndexByte(rel[:i], '/'), true {
+		if ign.skip(ign.prefix+rel[:i], isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// addFlag ...
+func (ign *ignoreRules) addFlag(line string) error {
+	r, ok, err := newIgnoreRule("", line)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("invalid ignore rule [%s]", line)
+	}
+	ign.flags = append(ign.flags, r)
+	return nil
+}
+
+// enter loads the .hqignore of the walked directory dir [sign only]
+func (ign *ignoreRules) enter(dir string) {
+	if ign == nil || !ign.load {
+		return
+	}
+	data, err := os.ReadFile(fixPath(dir) + _ignoreFile)
+	if err != nil {
+		return
+	}
+	base := strings.TrimSuffix(strings.TrimPrefix(fixPath(dir), ign.prefix), "/")
+	for line := range strings.SplitSeq(string(data), _linefeedS) {
+		r, ok, err := newIgnoreRule(base, line)
+		if err != nil && ign.err == nil {
+			ign.err = fmt.Errorf("%s [%w]", fixPath(dir)+_ignoreFile, err)
+		}
+		if ok {
+			ign.rules = append(ign.rules, r)
+		}
+	}
+}
+
+// skip reports if the walked name is excluded
+func (ign *ignoreRules) skip(name string, isDir bool) bool {
+	if ign == nil || len(ign.rules)+len(ign.flags) == 0 {
+		return false
+	}
+	rel := strings.TrimPrefix(name, ign.prefix)
+	var excluded bool
+	for _, list := range [2][]ignoreRule{ign.rules, ign.flags} {
+		for _, r := range list {
+			if r.match(rel, isDir) {
+				excluded = !r.negate
+			}
+		}
+	}
+	return excluded
+}
+
+// match ...
+func (r ignoreRule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.base != "" {
+		if !strings.HasPrefix(rel, r.base+"/") {
+			return false
+		}
+		rel = rel[len(r.base)+1:]
+	}
+	if !r.anchored {
+		ok, _ := path.Match(r.segs[0], path.Base(rel))
+		return ok
+	}
+	return matchSegs(r.segs, strings.Split(rel, "/"))
+}
+
+// matchSegs ...
+func matchSegs(pat, segs []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(segs); i++ {
+				if matchSegs(pat[1:], segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], segs[0]); !ok {
+			return false
+		}
+		pat, segs = pat[1:], segs[1:]
+	}
+	return len(segs) == 0
+}
+
+// encode returns the effective rule set as .hqMAP header lines [evaluation order]
+func (ign *ignoreRules) encode() []byte {
+	if ign == nil {
+		return nil
+	}
+	var b []byte
+	for _, list := range [2][]ignoreRule{ign.rules, ign.flags} {
+		for _, r := range list {
+			b = append(b, _ignoreRecord+strconv.Quote(r.base)+_space+r.raw+_linefeedS...)
+		}
+	}
+	return b
+}
+
+// decodeIgnoreRules parses the rule set recorded in the .hqMAP header of dir [verify]
+func decodeIgnoreRules(dir string, lines []string) (*ignoreRules, error) {
+	ign := &ignoreRules{prefix: fixPath(dir)}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, _ignoreRecord) {
+			continue
+		}
+		q, err := strconv.QuotedPrefix(line[len(_ignoreRecord):])
+		if err != nil {
+			return nil, fmt.Errorf("%w [input map is corrupt, rule: %s]", ErrCorruptContainer, line)
+		}
+		base, _ := strconv.Unquote(q)
+		raw := strings.TrimPrefix(line[len(_ignoreRecord)+len(q):], _space)
+		r, ok, err := newIgnoreRule(base, raw)
+		if err != nil || !ok {
+			return nil, fmt.Errorf("%w [input map is corrupt, rule: %s]", ErrCorruptContainer, line)
+		}
+		ign.rules = append(ign.rules, r)
+	}
+	return ign, nil
+}
@@ -0,0 +1,115 @@
+package hq
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreRuleMatch(t *testing.T) {
+	for _, tc := range []struct {
+		base, rule, rel string
+		isDir, want     bool
+	}{
+		{"", "*.log", "a.log", false, true},
+		{"", "*.log", "x/y/a.log", false, true},
+		{"", "*.log", "a.log.txt", false, false},
+		{"", "build/", "x/build", true, true},
+		{"", "build/", "x/build", false, false},
+		{"", "/a", "a", false, true},
+		{"", "/a", "x/a", false, false},
+		{"", "a/b", "a/b", false, true},
+		{"", "a/b", "x/a/b", false, false},
+		{"", "a/*/c", "a/b/c", false, true},
+		{"", "a/*/c", "a/b/b/c", false, false},
+		{"", "a/**/c", "a/c", false, true},
+		{"", "a/**/c", "a/b/b/c", false, true},
+		{"", "a/**/c", "x/a/b/c", false, false},
+		{"", "**/c", "c", false, true},
+		{"", "**/c", "a/b/c", false, true},
+		{"", "a/**", "a/b", false, true},
+		{"", "a/**", "a/b/c", true, true},
+		{"", "a/**", "b/a", false, false},
+		{"", `\#x`, "#x", false, true},
+		{"", `\!x`, "!x", false, true},
+		{"sub", "/a", "sub/a", false, true},
+		{"sub", "/a", "a", false, false},
+		{"sub", "*.log", "sub/x/a.log", false, true},
+		{"sub", "*.log", "subx/a.log", false, false},
+	} {
+		r, ok, err := newIgnoreRule(tc.base, tc.rule)
+		if err != nil || !ok {
+			t.Fatalf("rule %q: %v", tc.rule, err)
+		}
+		if got := r.match(tc.rel, tc.isDir); got != tc.want {
+			t.Errorf("base %q rule %q path %q [dir: %v]: got %v", tc.base, tc.rule, tc.rel, tc.isDir, got)
+		}
+	}
+}
+
+func TestIgnoreRuleParse(t *testing.T) {
+	for _, line := range []string{"", "   ", "# comment"} {
+		if _, ok, err := newIgnoreRule("", line); ok || err != nil {
+			t.Errorf("line %q: ok %v, err %v", line, ok, err)
+		}
+	}
+	for _, line := range []string{"/", "!", "a/[", "[z-a"} {
+		if _, _, err := newIgnoreRule("", line); err == nil {
+			t.Errorf("line %q: accepted", line)
+		}
+	}
+	r, _, _ := newIgnoreRule("", "!/a/b/")
+	if !r.negate || !r.dirOnly || !r.anchored || len(r.segs) != 2 {
+		t.Errorf("!/a/b/: %+v", r)
+	}
+}
+
+func TestIgnoreRulesNested(t *testing.T) {
+	root := t.TempDir()
+	for name, data := range map[string]string{
+		_ignoreFile:                       "*.log\n!keep.log\n",
+		filepath.Join("sub", _ignoreFile): "!*.log\nkeep.log\n",
+	} {
+		name = filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ign, err := newIgnoreRules(root, []string{"x.log"}, []string{"sub/x.log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ign.enter(root)
+	ign.enter(filepath.Join(root, "sub"))
+	if ign.err != nil {
+		t.Fatal(ign.err)
+	}
+	prefix := fixPath(root)
+	want := map[string]bool{
+		"a.log":        true,  // root rule
+		"keep.log":     false, // root re-include
+		"sub/a.log":    false, // nested re-include, later rule wins
+		"sub/keep.log": true,  // nested exclude
+		"x.log":        true,  // --exclude
+		"sub/x.log":    false, // --include after --exclude
+		"sub/a.txt":    false,
+	}
+	for rel, want := range want {
+		if got := ign.skip(prefix+rel, false); got != want {
+			t.Errorf("%s: got %v", rel, got)
+		}
+	}
+	dec, err := decodeIgnoreRules(root, strings.Split(string(ign.encode()), _linefeedS))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rel, want := range want {
+		if got := dec.skip(prefix+rel, false); got != want {
+			t.Errorf("decoded %s: got %v", rel, got)
+		}
+	}
+}
@@ -1,12 +1,14 @@
 package hq
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"io"
 	"io/fs"
 	"math/bits"
 	"os"
 	"runtime"
-	"sync"
 	"syscall"
 
 	"github.com/klauspost/compress/zstd"
@@ -18,69 +20,62 @@ const (
 	_modeSymlink uint32 = 1 << (32 - 1 - 4)
 )
 
-//
-// DISPLAY IO SECTION
-//
-
-// out ...
-func out(msg string) {
-	os.Stdout.Write([]byte(msg + "\n"))
-}
-
-// outPlain ...
-func outPlain(msg string) {
-	os.Stdout.Write([]byte(msg))
-}
-
 //
 // KEYBOARD IO SECTION
 //
 
 // getRune ...
-func getRune() byte {
-	if oldState, err := term.MakeRaw(0); err != nil {
-		panic(err)
-	} else {
-		//nolint:all - there is no alternative/reporting if this fails
-		defer term.Restore(0, oldState)
+func getRune() (byte, error) {
+	oldState, err := term.MakeRaw(0)
+	if err != nil {
+		return 0, fmt.Errorf("unable to read terminal [%w]", err)
 	}
+	//nolint:all - there is no alternative/reporting if this fails
+	defer term.Restore(0, oldState)
 	var buf [1]byte
-	if n, err := syscall.Read(0, buf[:]); n == 0 || err != nil {
-		panic(err)
+	n, err := syscall.Read(0, buf[:])
+	if n == 0 && err == nil {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return 0, fmt.Errorf("unable to read terminal [%w]", err)
 	}
-	return buf[0]
+	return buf[0], nil
 }
 
 // readLine ...
-func readLine(name string) string {
-	outPlain(name)
+func (u *ui) readLine(name string) (string, error) {
+	u.prompt(name)
 	line, exit := []byte{}, false
 	for {
-		v := getRune()
+		v, err := getRune()
+		if err != nil {
+			return "", err
+		}
 		switch v {
 		case 127, 8:
 			if l := len(line); l > 0 {
 				line = line[:l-1]
-				os.Stdout.Write(append([]byte{}, v))
+				u.e.Write(append([]byte{}, v))
 			}
 		case 13, 10:
 			exit = true
 		case 0:
 		default:
 			line = append(line, v)
-			os.Stdout.Write(append([]byte{}, v))
+			u.e.Write(append([]byte{}, v))
 		}
 		if exit {
 			break
 		}
 	}
-	os.Stdout.Write([]byte("\n"))
-	return string(line)
+	u.e.Write([]byte("\n"))
+	return string(line), nil
 }
 
 // readPassword ...
-func readPassword(name string, masked bool) string {
-	outPlain(name)
+func (u *ui) readPassword(name string, masked bool) (string, error) {
+	u.prompt(name)
 	var pass, bs, mask []byte
 	if masked {
 		bs = []byte("\b \b")
@@ -88,26 +83,29 @@ func readPassword(name string, masked bool) string {
 	}
 	exit := false
 	for {
-		v := getRune()
+		v, err := getRune()
+		if err != nil {
+			return "", err
+		}
 		switch v {
 		case 127, 8:
 			if l := len(pass); l > 0 {
 				pass = pass[:l-1]
-				os.Stdout.Write(bs)
+				u.e.Write(bs)
 			}
 		case 13, 10:
 			exit = true
 		case 0:
 		default:
 			pass = append(pass, v)
-			os.Stdout.Write(mask)
+			u.e.Write(mask)
 		}
 		if exit {
 			break
 		}
 	}
-	os.Stdout.Write([]byte("\n"))
-	return string(pass)
+	u.e.Write([]byte("\n"))
+	return string(pass), nil
 }
 
 //
@@ -146,12 +144,26 @@ func isPipe() bool {
 }
 
 // getPipe ...
-func getPipe() string {
+func getPipe() ([]byte, error) {
 	pipe, err := io.ReadAll(os.Stdin)
 	if err != nil {
-		errExit("while reading data from pipe")
+		return nil, fmt.Errorf("while reading data from pipe [%w]", err)
+	}
+	return pipe, nil
+}
+
+// ctxReader stops reading from r once ctx is done
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// Read ...
+func (r ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
 	}
-	return string(pipe)
+	return r.r.Read(p)
 }
 
 //
@@ -177,109 +189,37 @@ func isReadable(filename string) bool {
 	return true
 }
 
-// readFileErrExit ...
-func readFileErrExit(filename string) (data []byte) {
-	var err error
-	if data, err = os.ReadFile(filename); err != nil {
-		errExit("unable to read file [" + filename + "]" + "[" + err.Error() + "]")
+// readFile ...
+func readFile(filename string) ([]byte, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file [%s] [%w]", filename, err)
 	}
-	return data
+	return data, nil
 }
 
-// writeFileErrExit writes a file and flushes via f.Sync cache to phys disk
-func writeFileErrExit(filename string, data []byte, filemode fs.FileMode) {
+// writeFileSync writes a file and flushes via f.Sync cache to phys disk
+func writeFileSync(filename string, data []byte, filemode fs.FileMode) error {
 	if err := os.WriteFile(filename, data, filemode); err != nil {
-		errExit("unable to write file [" + filename + "]" + "[" + err.Error() + "]")
+		return fmt.Errorf("unable to write file [%s] [%w]", filename, err)
 	}
 	f, err := os.Open(filename)
 	if err != nil {
-		errExit("unable to [sync|verify] file on disk status [" + filename + "]" + "[" + err.Error() + "]")
+		return fmt.Errorf("unable to [sync|verify] file on disk status [%s] [%w]", filename, err)
 	}
 	defer f.Close()
-	err = f.Sync()
-	if err != nil {
-		errExit("unable to sync file to disk [" + filename + "]" + "[" + err.Error() + "]")
-	}
-}
-
-// recursiveFileList ...
-func recursiveFileList(path string, worker int) []string {
-	chanNames := make(chan string, 1)
-	chanReport := make(chan []string, 1)
-	go func() {
-		var list []string
-		for name := range chanNames {
-			list = append(list, name)
-		}
-		chanReport <- list
-	}()
-	dirlist := readDir(path)
-	for _, item := range dirlist {
-		name := fixPath(path) + item.Name()
-		switch {
-		case uint32(item.Type())&_modeDir != 0:
-			fastWalk(name, chanNames, worker)
-		case len(name) > 39 && name[:6] == ".hqMAP":
-			continue
-		default:
-			chanNames <- name
-		}
-	}
-	close(chanNames)
-	return <-chanReport
-}
-
-// walk ...
-func walk(path string, chanNames chan string) {
-	list, err := os.ReadDir(path)
-	if err != nil {
-		if path != "" {
-			errOut("unable to read directory [" + path + "] [" + err.Error() + "]")
-		}
-		return
-	}
-	for _, item := range list {
-		name := path + "/" + item.Name()
-		switch {
-		case uint32(item.Type())&_modeDir != 0:
-			walk(name, chanNames)
-		default:
-			chanNames <- name
-		}
+	if err = f.Sync(); err != nil {
+		return fmt.Errorf("unable to sync file to disk [%s] [%w]", filename, err)
 	}
+	return nil
 }
 
-// fastWalk ...
-func fastWalk(path string, chanNames chan string, threads int) {
-	bg := sync.WaitGroup{}
-	chanDir := make(chan string, 10000)
-	for range threads {
-		go func() {
-			for path := range chanDir {
-				list, err := os.ReadDir(path)
-				if err != nil {
-					errOut("unable to read directory [" + path + "] [" + err.Error() + "]")
-					bg.Done()
-					continue
-				}
-				for _, item := range list {
-					name := path + "/" + item.Name()
-					switch {
-					case uint32(item.Type())&_modeDir != 0:
-						bg.Add(1)
-						chanDir <- name
-					default:
-						chanNames <- name
-					}
-				}
-				bg.Done()
-			}
-		}()
+// keyRing ...
+func keyRing(path string) string {
+	if path == "" || path[len(path)-1] == '/' {
+		return path
 	}
-	bg.Add(1)
-	chanDir <- path
-	bg.Wait()
-	close(chanDir)
+	return path + "/"
 }
 
 // fixPath ...
@@ -295,12 +235,12 @@ func fixPath(path string) string {
 }
 
 // readDir ...
-func readDir(path string) (list []fs.DirEntry) {
-	var err error
-	if list, err = os.ReadDir(path); err != nil {
-		errExit("unable to list directory [" + path + "]")
+func readDir(path string) ([]fs.DirEntry, error) {
+	list, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list directory [%s] [%w]", path, err)
 	}
-	return list
+	return list, nil
 }
 
 //
@@ -308,25 +248,70 @@ func readDir(path string) (list []fs.DirEntry) {
 //
 
 // compressWriteFile ...
-func compressWriteFile(filename string, data []byte, level int, filemode fs.FileMode) {
-	writeFileErrExit(filename, compressZstd(data, level), filemode)
+func compressWriteFile(filename string, data []byte, level int, filemode fs.FileMode) error {
+	return writeFileSync(filename, compressZstd(data, level), filemode)
 }
 
 // decompressReadFile ...
-func decompressReadFile(filename string) (data []byte) {
-	return decompressZstd(readFileErrExit(filename))
+func decompressReadFile(filename string) ([]byte, error) {
+	data, err := readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return decompressZstd(data)
+}
+
+// decompressReadHeader returns the decompressed data of filename up to the first empty line [max 1 MB]
+func decompressReadHeader(filename string) ([]byte, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file [%s] [%w]", filename, err)
+	}
+	defer f.Close()
+	d, err := zstd.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("zstd decode [%w]", err)
+	}
+	defer d.Close()
+	var data []byte
+	block := make([]byte, 4096)
+	for len(data) < 1<<20 {
+		n, err := d.Read(block)
+		data = append(data, block[:n]...)
+		if i := bytes.Index(data, []byte("\n\n")); i >= 0 {
+			return data[:i+1], nil
+		}
+		if err == io.EOF {
+			return data, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("zstd decode [%s] [%w]", filename, err)
+		}
+	}
+	return data, nil
 }
 
 // decompressZstd ...
-func decompressZstd(message []byte) []byte {
+func decompressZstd(message []byte) ([]byte, error) {
 	e, _ := zstd.NewReader(nil)
-	dst, _ := e.DecodeAll(message, nil)
-	e.Close()
-	return dst
+	defer e.Close()
+	dst, err := e.DecodeAll(message, nil)
+	if err != nil {
+		return nil, fmt.Errorf("zstd decode [%w]", err)
+	}
+	return dst, nil
 }
 
 // compressZstd ...
 func compressZstd(message []byte, level int) []byte {
+	e, _ := newZstdWriter(nil, level)
+	dst := e.EncodeAll(message, nil)
+	e.Close()
+	return dst
+}
+
+// newZstdWriter ...
+func newZstdWriter(w io.Writer, level int) (*zstd.Encoder, error) {
 	// @UPSTREAM FIX ISSUE
 	// compress/zstd encoder MaxWindowSize 32bit OS mem alloc fail hack
 	threads := runtime.NumCPU()
@@ -334,7 +319,7 @@ func compressZstd(message []byte, level int) []byte {
 		runtime.GC()
 		threads = 1
 	}
-	e, _ := zstd.NewWriter(nil,
+	return zstd.NewWriter(w,
 		// zstd.WithWindowSize(zstd.MaxWindowSize),
 		zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)),
 		zstd.WithEncoderCRC(false),
@@ -343,7 +328,51 @@ func compressZstd(message []byte, level int) []byte {
 		zstd.WithAllLitEntropyCompression(false),
 		zstd.WithNoEntropyCompression(true),
 		zstd.WithEncoderConcurrency(threads))
-	dst := e.EncodeAll(message, nil)
-	e.Close()
-	return dst
+}
+
+// compressFile is a streaming zstd file writer [single frame]
+type compressFile struct {
+	name string
+	file *os.File
+	enc  *zstd.Encoder
+}
+
+// createCompressFile ...
+func createCompressFile(filename string, level int, filemode fs.FileMode) (*compressFile, error) {
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, filemode)
+	if err != nil {
+		return nil, fmt.Errorf("unable to write file [%s] [%w]", filename, err)
+	}
+	enc, err := newZstdWriter(f, level)
+	if err != nil {
+		f.Close()
+		os.Remove(filename)
+		return nil, fmt.Errorf("zstd encode [%w]", err)
+	}
+	return &compressFile{name: filename, file: f, enc: enc}, nil
+}
+
+// Write ...
+func (c *compressFile) Write(p []byte) (int, error) {
+	return c.enc.Write(p)
+}
+
+// Close flushes the zstd frame and syncs the file to disk
+func (c *compressFile) Close() error {
+	if err := c.enc.Close(); err != nil {
+		c.file.Close()
+		return fmt.Errorf("zstd encode [%s] [%w]", c.name, err)
+	}
+	if err := c.file.Sync(); err != nil {
+		c.file.Close()
+		return fmt.Errorf("unable to sync file to disk [%s] [%w]", c.name, err)
+	}
+	return c.file.Close()
+}
+
+// abort removes the partial file
+func (c *compressFile) abort() {
+	c.enc.Close()
+	c.file.Close()
+	os.Remove(c.name)
 }
@@ -0,0 +1,207 @@
+package hq
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// jsonSigner ...
+type jsonSigner struct {
+	Tag       string `json:"tag"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// jsonReport is the final [--json] record of a sign|verify|cosign|generate operation
+type jsonReport struct {
+	Type         string       `json:"type"` // signature|verify|identity|diff|map|chain
+	Action       string       `json:"action"`
+	Owner        string       `json:"owner,omitempty"`
+	Tag          string       `json:"tag,omitempty"`
+	File         string       `json:"file,omitempty"`
+	Dir          string       `json:"dir,omitempty"`
+	From         string       `json:"from,omitempty"`
+	To           string       `json:"to,omitempty"`
+	Prev         string       `json:"prev,omitempty"`
+	Link         string       `json:"link,omitempty"`
+	Timestamp    int64        `json:"timestamp,omitempty"`
+	Time         string       `json:"time,omitempty"`
+	Valid        bool         `json:"valid"`
+	Trust        string       `json:"trust,omitempty"`
+	Signers      int          `json:"signers,omitempty"`
+	Cosigners    []jsonSigner `json:"cosigners,omitempty"`
+	FilesTotal   uint64       `json:"files_total,omitempty"`
+	FilesOK      uint64       `json:"files_ok,omitempty"`
+	FilesFail    uint64       `json:"files_fail,omitempty"`
+	FilesNew     uint64       `json:"files_new,omitempty"`
+	FilesChanged uint64       `json:"files_changed,omitempty"`
+	FilesRemoved uint64       `json:"files_removed,omitempty"`
+	FilesReused  uint64       `json:"files_reused,omitempty"`
+	FilesRenamed uint64       `json:"files_renamed,omitempty"`
+	FilesSkipped uint64       `json:"files_skipped,omitempty"`
+	FilesHashed  uint64       `json:"files_hashed,omitempty"`
+	Script       string       `json:"script,omitempty"`
+	Error        string       `json:"error,omitempty"`
+	ErrorCode    string       `json:"error_code,omitempty"`
+	Duration     string       `json:"duration,omitempty"`
+}
+
+// jsonFile is a streamed [NDJSON] per-file directory verify|diff|watch event
+type jsonFile struct {
+	Type         string `json:"type"` // file
+	File         string `json:"file"`
+	From         string `json:"from,omitempty"`
+	Reason       string `json:"reason"`
+	Expected     string `json:"expected,omitempty"`
+	Found        string `json:"found,omitempty"`
+	CodeExpected string `json:"code_expected,omitempty"`
+	CodeFound    string `json:"code_found,omitempty"`
+	Time         string `json:"time,omitempty"`  // watch event [RFC3339]
+	Error        string `json:"error,omitempty"` // watch rescan walk error
+}
+
+// reasonCode is the failure of a file [verifyMap failed.reason, sign time map error, watch]
+type reasonCode int
+
+// reason codes
+const (
+	_reasonUnreadable    reasonCode = 0
+	_reasonRemoved       reasonCode = 1
+	_reasonPermission    reasonCode = 2
+	_reasonModified      reasonCode = 3
+	_reasonCodeUnchanged reasonCode = 4  // content modified, code hash unchanged
+	_reasonCodeChanged   reasonCode = 5  // content modified, code hash changed
+	_reasonCodeFailed    reasonCode = 6  // content modified, code hash failed
+	_reasonType          reasonCode = 7  // meta data drift [_reasonType .. _reasonOwner]
+	_reasonSymlink       reasonCode = 8  // meta data drift
+	_reasonMode          reasonCode = 9  // meta data drift
+	_reasonOwner         reasonCode = 10 // meta data drift
+	_reasonNotSigned     reasonCode = 11 // recorded sign time error
+)
+
+// json reasons [also the .hqMAP err=<reason> value of a sign time error]
+var _jsonReason = map[reasonCode]string{
+	_reasonUnreadable:    "unreadable",
+	_reasonRemoved:       "removed",
+	_reasonPermission:    "permission",
+	_reasonModified:      "modified",
+	_reasonCodeUnchanged: "modified_code_unchanged",
+	_reasonCodeChanged:   "modified_code_changed",
+	_reasonCodeFailed:    "code_hash_failed",
+	_reasonType:          "type_changed",
+	_reasonSymlink:       "symlink_changed",
+	_reasonMode:          "mode_changed",
+	_reasonOwner:         "owner_changed",
+	_reasonNotSigned:     "not_signed",
+}
+
+// reason codes for files not covered by the .hqMAP, files confirmed by hash [quick verify]
+const (
+	_jsonNew       = "new"
+	_jsonConfirmed = "confirmed"
+)
+
+// error codes for the exported sentinel errors
+var _jsonErrCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrKeyNotFound, "key_not_found"},
+	{ErrTagChecksum, "tag_checksum"},
+	{ErrSignatureMismatch, "signature_mismatch"},
+	{ErrUntrustedKey, "untrusted_key"},
+	{ErrCorruptContainer, "corrupt_container"},
+	{ErrCorruptKey, "corrupt_key"},
+	{ErrPassphrase, "passphrase"},
+	{ErrPassphraseRequired, "passphrase_required"},
+	{ErrMapNotFound, "map_not_found"},
+	{ErrFilesModified, "files_modified"},
+	{ErrThreshold, "threshold"},
+	{ErrPolicy, "policy"},
+	{ErrChainBroken, "chain_broken"},
+}
+
+// jsonErrCode ...
+func jsonErrCode(err error) string {
+	for _, e := range _jsonErrCodes {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+	return "error"
+}
+
+// jsonLine encodes v as a single NDJSON line
+func jsonLine(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(jsonReport{Type: "error", Error: err.Error(), ErrorCode: "error"})
+	}
+	return string(b)
+}
+
+// String returns the json reason of r [unknown: unreadable]
+func (r reasonCode) String() string {
+	if s, ok := _jsonReason[r]; ok {
+		return s
+	}
+	return _jsonReason[_reasonUnreadable]
+}
+
+// parseReason returns the reason code of the json reason s [unknown: unreadable]
+func parseReason(s string) reasonCode {
+	for r, name := range _jsonReason {
+		if name == s {
+			return r
+		}
+	}
+	return _reasonUnreadable
+}
+
+// reportJSON writes the final record for action, id may be nil if the operation failed early
+func (c *Config) reportJSON(action string, id *HQ, err error) {
+	r := jsonReport{Type: "verify", Action: action}
+	switch action {
+	case "sign", "cosign":
+		r.Type = "signature"
+	case "generate":
+		r.Type = "identity"
+	}
+	if id != nil {
+		r.Owner = unpad(id.ID.OWNER)
+		r.Tag = string(id.ID.TAG[:])
+		r.File = id.IO.FileName
+		r.Dir = id.IO.DirName
+		if ts, err := strconv.ParseInt(id.IO.TSS, 10, 64); err == nil {
+			r.Timestamp, r.Time = ts, time.Unix(ts, 0).UTC().Format(time.RFC3339)
+		}
+		if id.IO.Trust != TrustUnknown {
+			r.Trust = id.IO.Trust.String()
+		}
+		r.Signers = id.IO.Signers
+		for _, cs := range id.IO.COSIG {
+			ts, _ := strconv.ParseInt(cs.TSS, 10, 64)
+			r.Cosigners = append(r.Cosigners, jsonSigner{Tag: string(cs.TAG[:]), Timestamp: ts})
+		}
+		r.FilesTotal, r.FilesFail, r.FilesNew = id.IO.FilesTotal, id.IO.FilesFail, id.IO.FilesNew
+		r.FilesChanged, r.FilesRemoved, r.FilesReused = id.IO.FilesChanged, id.IO.FilesRemoved, id.IO.FilesReused
+		if id.IO.FilesTotal >= id.IO.FilesFail {
+			r.FilesOK = id.IO.FilesTotal - id.IO.FilesFail
+		}
+		if id.IO.Quick && r.FilesOK >= id.IO.FilesSkipped {
+			r.FilesSkipped, r.FilesHashed = id.IO.FilesSkipped, r.FilesOK-id.IO.FilesSkipped
+		}
+		if id.IO.IsExec && r.Type == "verify" {
+			if script, err := decompressZstd(id.IO.SCRIPT); err == nil {
+				r.Script = string(script)
+			}
+		}
+		r.Duration = time.Since(id.IO.Start).String()
+	}
+	r.Valid = err == nil
+	if err != nil {
+		r.Error, r.ErrorCode = err.Error(), jsonErrCode(err)
+	}
+	c.ui().out(jsonLine(r))
+}
@@ -0,0 +1,18 @@
+package hq
+
+import "testing"
+
+func TestReasonCodes(t *testing.T) {
+	for r := _reasonUnreadable; r <= _reasonNotSigned; r++ {
+		s, ok := _jsonReason[r]
+		if !ok {
+			t.Fatalf("reason %d has no json reason", r)
+		}
+		if r.String() != s || parseReason(s) != r {
+			t.Errorf("reason %d [%s] does not round trip", r, s)
+		}
+	}
+	if _hashOK.String() != "unreadable" || parseReason("unknown") != _reasonUnreadable {
+		t.Error("unknown reasons must fall back to unreadable")
+	}
+}
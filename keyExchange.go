@@ -0,0 +1,206 @@
+package hq
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// const
+const (
+	_armorKey     = "PUBLIC KEY"
+	_armorVersion = "1"
+	_armorWidth   = 64
+)
+
+// armorField ...
+type armorField struct {
+	name, value string
+}
+
+// encodeArmor encodes a versioned hq text block [-----BEGIN HQ <kind>-----]
+func encodeArmor(kind string, fields []armorField, body []byte) []byte {
+	var b bytes.Buffer
+	data := base64.StdEncoding.EncodeToString(body)
+	b.WriteString("-----BEGIN HQ " + kind + "-----" + _linefeedS)
+	b.WriteString("Version: " + _armorVersion + _linefeedS)
+	for _, f := range fields {
+		b.WriteString(f.name + ": " + f.value + _linefeedS)
+	}
+	b.WriteString(_linefeedS)
+	for len(data) > _armorWidth {
+		b.WriteString(data[:_armorWidth] + _linefeedS)
+		data = data[_armorWidth:]
+	}
+	b.WriteString(data + _linefeedS)
+	b.WriteString("-----END HQ " + kind + "-----" + _linefeedS)
+	return b.Bytes()
+}
+
+// isArmor ...
+func isArmor(in []byte, kind string) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(in), []byte("-----BEGIN HQ "+kind+"-----"))
+}
+
+// decodeArmor parses a versioned hq text block, returns header fields and decoded body
+func decodeArmor(in []byte, kind string) (map[string]string, []byte, error) {
+	var (
+		data      strings.Builder
+		header    = map[string]string{}
+		inHeader  = true
+		begin     = false
+		end       = false
+		corrupted = fmt.Errorf("%w [armored %s block]", ErrCorruptKey, strings.ToLower(kind))
+	)
+	for line := range strings.Lines(string(in)) {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "-----BEGIN HQ "+kind+"-----":
+			begin = true
+		case !begin:
+		case line == "-----END HQ "+kind+"-----":
+			end = true
+		case end:
+		case line == "":
+			inHeader = false
+		case inHeader:
+			k, v, ok := strings.Cut(line, ":")
+			if !ok {
+				return nil, nil, corrupted
+			}
+			header[k] = strings.TrimSpace(v)
+		default:
+			data.WriteString(line)
+		}
+	}
+	if !begin || !end {
+		return nil, nil, corrupted
+	}
+	if header["Version"] != _armorVersion {
+		return nil, nil, fmt.Errorf("%w [unsupported armor version %s]", ErrCorruptKey, header["Version"])
+	}
+	body, err := base64.StdEncoding.DecodeString(data.String())
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w [base64 %s part is defect]", ErrCorruptKey, strings.ToLower(kind))
+	}
+	return header, body, nil
+}
+
+// encodeKeyArmor encodes the public key as versioned, self-certifying [NameTAG] text block
+func (id *HQ) encodeKeyArmor(created time.Time) []byte {
+	return encodeArmor(_armorKey, []armorField{
+		{"Owner", unpad(id.ID.OWNER)},
+		{"NameTAG", string(id.ID.TAG[:])},
+		{"Created", strconv.FormatInt(created.Unix(), 10)},
+	}, id.ID.KEY[:])
+}
+
+// decodeKeyArmor parses an armored public key block, the NameTAG checksum is
+// re-computed and must match, returns the creation time
+func (id *HQ) decodeKeyArmor(in []byte) (time.Time, error) {
+	var created time.Time
+	header, k, err := decodeArmor(in, _armorKey)
+	if err != nil {
+		return created, err
+	}
+	if err := validateOwner(header["Owner"]); err != nil {
+		return created, fmt.Errorf("%w [%s]", ErrCorruptKey, err.Error())
+	}
+	if len(k) != PublicKeySize {
+		return created, fmt.Errorf("%w [key size]", ErrCorruptKey)
+	}
+	if ts, err := strconv.ParseInt(header["Created"], 10, 64); err == nil {
+		created = time.Unix(ts, 0)
+	}
+	id.ID.OWNER = pad(header["Owner"])
+	copy(id.ID.KEY[:], k)
+	id.genTag()
+	if string(id.ID.TAG[:]) != header["NameTAG"] {
+		return created, fmt.Errorf("%w [%s]", ErrTagChecksum, header["NameTAG"])
+	}
+	return created, nil
+}
+
+// exportKey returns the armored public key block for nametag [default: me]
+func (c *Config) exportKey(nametag string) ([]byte, error) {
+	id := NewHQ(c)
+	if nametag == "" || nametag == "." {
+		nametag = _me
+	}
+	if err := id.readPublicKey(nametag); err != nil {
+		return nil, err
+	}
+	keystore, err := id.keyStore()
+	if err != nil {
+		return nil, err
+	}
+	created := time.Now()
+	if fi, err := os.Stat(keystore + string(id.ID.TAG[:])); err == nil {
+		created = fi.ModTime()
+	}
+	return id.encodeKeyArmor(created), nil
+}
+
+// importKey ...
+func (c *Config) importKey(armor []byte) (*HQ, error) {
+	id := NewHQ(c)
+	if _, err := id.decodeKeyArmor(armor); err != nil {
+		return nil, err
+	}
+	exist := NewHQ(c)
+	switch err := exist.readPublicKey(string(id.ID.TAG[:])); {
+	case err == nil:
+		return id, nil // NameTAG is a checksum over owner & key, nothing to update
+	case !errors.Is(err, ErrKeyNotFound):
+		return nil, err
+	}
+	return id, id.writePublicKey()
+}
+
+// runExport ...
+func (c *Config) runExport() error {
+	armor, err := c.exportKey(c.FileName)
+	if err != nil {
+		return err
+	}
+	c.ui().outPlain(string(armor))
+	return nil
+}
+
+// runImport ...
+func (c *Config) runImport() error {
+	var (
+		armor []byte
+		err   error
+	)
+	switch {
+	case c.FileName == _pipe:
+		armor, err = getPipe()
+	default:
+		armor, err = readFile(c.FileName)
+	}
+	if err != nil {
+		return err
+	}
+	if isArmor(armor, _armorRevocation) {
+		id, err := c.importRevocation(armor)
+		if err != nil {
+			return err
+		}
+		id.reportRevocation()
+		return nil
+	}
+	id, err := c.importKey(armor)
+	if err != nil {
+		return err
+	}
+	id.IO.ReportTime = false
+	id.IO.ReportValid = true
+	id.report()
+	return nil
+}
@@ -0,0 +1,217 @@
+package hq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// mapPolicy is the .hqMAP retention policy, the new map is always kept
+type mapPolicy struct {
+	last   int // keep the n most recent maps
+	daily  int // keep the most recent map of each of the last n days [UTC]
+	weekly int // keep the most recent map of each of the last n weeks [ISO]
+}
+
+// isMapFile reports if name [basename] is a .hqMAP or its signature
+func isMapFile(name string) bool {
+	return len(name) > 39 && name[:7] == ".hqMAP."
+}
+
+// mapPolicy ...
+func (c *Config) mapPolicy() mapPolicy {
+	if isMapClean() {
+		return mapPolicy{}
+	}
+	return mapPolicy{last: c.KeepLast, daily: c.KeepDaily, weekly: c.KeepWeekly}
+}
+
+// listMaps returns all .hqMAP files [basename] of dir, oldest first
+func listMaps(dir string) ([]string, error) {
+	list, err := readDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var maps []string
+	for _, entry := range list {
+		name := entry.Name()
+		if len(name) == 42 && name[:7] == ".hqMAP." && name[38:] == _compressedFileExt && !entry.IsDir() {
+			maps = append(maps, name)
+		}
+	}
+	slices.Sort(maps) // fixed width unix time stamp
+	return maps, nil
+}
+
+// prunePlan returns the .hqMAP files of maps [oldest first] not covered by the policy p
+func prunePlan(maps []string, keep string, p mapPolicy) map[string]bool {
+	keepSet := map[string]bool{keep: true}
+	days, weeks := make(map[string]bool), make(map[string]bool)
+	for i := range maps {
+		name := maps[len(maps)-1-i]
+		t := time.Unix(mapUnix(name), 0).UTC()
+		day := t.Format(time.DateOnly)
+		year, week := t.ISOWeek()
+		yw := strconv.Itoa(year) + "-" + strconv.Itoa(week)
+		if i < p.last {
+			keepSet[name] = true
+		}
+		if !days[day] && len(days) < p.daily {
+			days[day], keepSet[name] = true, true
+		}
+		if !weeks[yw] && len(weeks) < p.weekly {
+			weeks[yw], keepSet[name] = true, true
+		}
+	}
+	remove := make(map[string]bool)
+	for _, name := range maps {
+		if !keepSet[name] {
+			remove[name] = true
+		}
+	}
+	return remove
+}
+
+// pruneMaps removes all .hqMAP files [incl. signatures] of dir not covered by the policy p
+func pruneMaps(dir, keep string, p mapPolicy) error {
+	maps, err := listMaps(dir)
+	if err != nil {
+		return err
+	}
+	remove := prunePlan(maps, keep, p)
+	for _, name := range maps {
+		if !remove[name] {
+			continue
+		}
+		for _, f := range []string{name, name + _extSignature} {
+			if err := os.Remove(fixPath(dir) + f); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("unable to remove .hqMAP [%s] [%w]", f, err)
+			}
+		}
+	}
+	return nil
+}
+
+// mapInfo verifies the signature of the .hqMAP name [basename] of the directory c.FileName
+func (c *Config) mapInfo(name string) MapInfo {
+	info := MapInfo{FileName: fixPath(c.mapDir()) + name, TSS: strconv.FormatInt(mapUnix(name), 10)}
+	id := NewHQ(c)
+	id.IO.Silent = true
+	id.IO.DirName = c.FileName
+	id.IO.FileName = info.FileName + _extSignature
+	data, err := decompressReadFile(info.FileName)
+	if err == nil {
+		err = walkMap(data, func(string, mapEntry) error {
+			info.FilesTotal++
+			return nil
+		})
+	}
+	if err == nil {
+		err = id.pinKey(c)
+	}
+	var container []byte
+	if err == nil {
+		container, err = readFile(id.IO.FileName)
+	}
+	if err == nil {
+		err = id.decodeSig(container)
+	}
+	if err == nil {
+		info.ID = id.ID
+		id.IO.MSG, err = getMSGHash(info.FileName)
+	}
+	if err == nil {
+		err = id.verifySigs(c.FileName)
+	}
+	info.Trust, info.Signers, info.Valid, info.Err = id.IO.Trust, id.IO.Signers, err == nil, err
+	return info
+}
+
+// maps lists all .hqMAP files of the directory c.FileName, oldest first
+func (c *Config) maps(ctx context.Context) ([]MapInfo, error) {
+	names, err := listMaps(c.mapDir())
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]MapInfo, 0, len(names))
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		infos = append(infos, c.mapInfo(name))
+	}
+	return infos, nil
+}
+
+// tag ...
+func (m MapInfo) tag() string {
+	if m.ID.TAG == [30]byte{} {
+		return ""
+	}
+	return string(m.ID.TAG[:])
+}
+
+// line returns the text report line [time stamp, signer, files]
+func (m MapInfo) line() string {
+	tag := m.tag()
+	if tag == "" {
+		tag = "<unknown signer>"
+	}
+	ts, _ := strconv.ParseInt(m.TSS, 10, 64)
+	return m.TSS + _space + time.Unix(ts, 0).UTC().Format(time.RFC3339) + _space + tag + _space + strconv.FormatUint(m.FilesTotal, 10) + " files"
+}
+
+// jsonReport returns the [--json] map record
+func (m MapInfo) jsonReport(action, dir string) jsonReport {
+	ts, _ := strconv.ParseInt(m.TSS, 10, 64)
+	r := jsonReport{
+		Type:       "map",
+		Action:     action,
+		Tag:        m.tag(),
+		File:       m.FileName,
+		Dir:        dir,
+		Timestamp:  ts,
+		Time:       time.Unix(ts, 0).UTC().Format(time.RFC3339),
+		Valid:      m.Valid,
+		Signers:    m.Signers,
+		FilesTotal: m.FilesTotal,
+	}
+	if r.Tag != "" {
+		r.Owner = unpad(m.ID.OWNER)
+	}
+	if m.Trust != TrustUnknown {
+		r.Trust = m.Trust.String()
+	}
+	if m.Err != nil {
+		r.Error, r.ErrorCode = m.Err.Error(), jsonErrCode(m.Err)
+	}
+	return r
+}
+
+// runMaps [hq maps list <dir>]
+func (c *Config) runMaps(ctx context.Context) error {
+	infos, err := c.maps(ctx)
+	if err != nil {
+		return err
+	}
+	u := c.ui()
+	if c.JSON {
+		for _, m := range infos {
+			u.out(jsonLine(m.jsonReport("maps", c.FileName)))
+		}
+		return nil
+	}
+	valid, invalid := u.valid, u.fail
+	for _, m := range infos {
+		status := valid
+		if !m.Valid {
+			status = invalid + " [" + m.Err.Error() + "]"
+		}
+		u.out(m.line() + _space + status)
+	}
+	return nil
+}
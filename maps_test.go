@@ -0,0 +1,75 @@
+package hq
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// testMapName returns the .hqMAP basename of t
+func testMapName(t testing.TB, ts time.Time) string {
+	t.Helper()
+	tss := strconv.FormatInt(ts.Unix(), 10)
+	stamp, err := unix2RFC3339(tss)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ".hqMAP." + tss + "." + stamp + _compressedFileExt
+}
+
+func TestPrunePlan(t *testing.T) {
+	// two maps per day [06:00, 18:00 UTC], Fri 2026-09-25 till Thu 2026-10-15
+	var list []string
+	day := time.Date(2026, 9, 25, 0, 0, 0, 0, time.UTC)
+	for d := range 21 {
+		for _, h := range []int{6, 18} {
+			list = append(list, testMapName(t, day.AddDate(0, 0, d).Add(time.Duration(h)*time.Hour)))
+		}
+	}
+	keep := list[len(list)-1]
+	evening := func(days ...int) []int {
+		var idx []int
+		for _, d := range days {
+			idx = append(idx, 2*d+1)
+		}
+		return idx
+	}
+	for _, tc := range []struct {
+		name string
+		p    mapPolicy
+		kept []int
+	}{
+		{"none", mapPolicy{}, []int{41}},
+		{"last", mapPolicy{last: 3}, []int{39, 40, 41}},
+		{"daily", mapPolicy{daily: 7}, evening(14, 15, 16, 17, 18, 19, 20)},
+		{"weekly", mapPolicy{weekly: 3}, evening(9, 16, 20)},                                    // Sun 10-04, Sun 10-11, Thu 10-15
+		{"all", mapPolicy{last: 3, daily: 2, weekly: 4}, append(evening(2, 9, 16), 39, 40, 41)}, // overlapping rules
+		{"beyond", mapPolicy{last: 100}, nil},
+	} {
+		remove := prunePlan(list, keep, tc.p)
+		var kept []int
+		for i, name := range list {
+			if !remove[name] {
+				kept = append(kept, i)
+			}
+		}
+		if tc.kept == nil {
+			if len(remove) != 0 {
+				t.Errorf("%s: removed %d maps", tc.name, len(remove))
+			}
+			continue
+		}
+		slices.Sort(tc.kept)
+		if !slices.Equal(kept, tc.kept) {
+			t.Errorf("%s: kept %v, want %v", tc.name, kept, tc.kept)
+		}
+	}
+
+	// the new map is kept even if it is not the most recent one
+	remove := prunePlan(list, list[0], mapPolicy{last: 1})
+	if remove[list[0]] || remove[keep] || len(remove) != len(list)-2 {
+		t.Errorf("keep: removed %v", slices.Sorted(maps.Keys(remove)))
+	}
+}
@@ -0,0 +1,58 @@
+package hq
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"time"
+)
+
+// readerSign signs the message read from reader, without any filesystem access
+// besides the keystore, optional signify signatures are not supported
+func (c *Config) readerSign(ctx context.Context, reader io.Reader) (*HQ, error) {
+	id := NewHQ(c)
+	id.setPass(c)
+	id.IO.Signify = false
+	id.IO.TSS = strconv.FormatInt(id.IO.Start.Unix(), 10)
+	type msg struct {
+		hash [HashSize]byte
+		err  error
+	}
+	ctxHash, cancel := context.WithCancel(ctx)
+	defer cancel()
+	chanHash := make(chan msg, 1)
+	go func() {
+		h, err := getMSGHashReader(ctxReader{ctx: ctxHash, r: reader})
+		chanHash <- msg{hash: h, err: err}
+		close(chanHash)
+	}()
+	// abort stops the hashing, reader is released before the caller gets control back
+	abort := func(err error) (*HQ, error) {
+		cancel()
+		<-chanHash
+		return nil, err
+	}
+	if err := id.readPublicKey(id.signAs()); err != nil {
+		return abort(err)
+	}
+	if err := id.passEntry("pending stream sign operation"); err != nil {
+		return abort(err)
+	}
+	h := <-chanHash
+	if h.err != nil {
+		return nil, h.err
+	}
+	id.IO.MSG = h.hash
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	id.IO.Start = time.Now()
+	if err := id.unlockHQ(); err != nil {
+		return nil, err
+	}
+	if err := id.genSig(); err != nil {
+		return nil, err
+	}
+	id.report()
+	return id, nil
+}
@@ -0,0 +1,32 @@
+package hq
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// readerVerify verifies the .hqs|.hqx container sig against the message read from reader
+func (c *Config) readerVerify(ctx context.Context, reader io.Reader, sig []byte) (*HQ, error) {
+	id := NewHQ(c)
+	id.IO.ReportValid = false
+	if err := id.pinKey(c); err != nil {
+		return nil, err
+	}
+	if err := id.decodeSig(sig); err != nil {
+		return nil, err
+	}
+	if !id.IO.IsExec {
+		if reader == nil {
+			return nil, errors.New("message reader required [.hqs container]")
+		}
+		var err error
+		if id.IO.MSG, err = getMSGHashReader(ctxReader{ctx: ctx, r: reader}); err != nil {
+			return nil, err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return id, id.verifySigs("")
+}
@@ -0,0 +1,198 @@
+package hq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// const
+const (
+	_armorRevocation = "REVOCATION"
+	_extRevoked      = ".revoked"
+	_revokeMagic     = "HQ REVOCATION#"
+)
+
+// revocation ...
+type revocation struct {
+	tss    string // revocation time stamp [unix seconds]
+	reason string // free text reason
+}
+
+// revokeMsg binds the revocation statement [magic, reason] into the signed message,
+// OWNER, TAG and TSS are added by genSig/validateSig
+func revokeMsg(reason string) [HashSize]byte {
+	return hashWrap512([]byte(_revokeMagic + reason))
+}
+
+// encodeRevocation ...
+func (id *HQ) encodeRevocation(r revocation) []byte {
+	return encodeArmor(_armorRevocation, []armorField{
+		{"Owner", unpad(id.ID.OWNER)},
+		{"NameTAG", string(id.ID.TAG[:])},
+		{"Revoked", r.tss},
+		{"Reason", r.reason},
+	}, id.IO.SIG[:])
+}
+
+// decodeRevocation parses an armored revocation certificate, the public key for the
+// listed NameTAG must be present in the keystore, the certificate signature must validate
+func (id *HQ) decodeRevocation(in []byte) (revocation, error) {
+	var r revocation
+	header, sig, err := decodeArmor(in, _armorRevocation)
+	if err != nil {
+		return r, err
+	}
+	if len(sig) != SignatureSize {
+		return r, fmt.Errorf("%w [revocation signature size]", ErrCorruptKey)
+	}
+	if _, err := strconv.ParseInt(header["Revoked"], 10, 64); err != nil {
+		return r, fmt.Errorf("%w [revocation time stamp]", ErrCorruptKey)
+	}
+	r.tss, r.reason = header["Revoked"], header["Reason"]
+	if err := id.readPublicKey(header["NameTAG"]); err != nil {
+		return r, err
+	}
+	id.IO.TSS = r.tss
+	id.IO.MSG = revokeMsg(r.reason)
+	copy(id.IO.SIG[:], sig)
+	if !id.validateSig() {
+		return r, fmt.Errorf("%w [revocation certificate for %s]", ErrSignatureMismatch, header["NameTAG"])
+	}
+	return r, nil
+}
+
+// revocationFile ...
+func (id *HQ) revocationFile(nametag string) (string, error) {
+	keystore, err := id.keyStore()
+	if err != nil {
+		return "", err
+	}
+	return keystore + nametag + _extRevoked, nil
+}
+
+// writeRevocation stores the certificate next to the public key [<keystore>/<NameTAG>.revoked]
+func (id *HQ) writeRevocation(cert []byte) error {
+	filename, err := id.revocationFile(string(id.ID.TAG[:]))
+	if err != nil {
+		return err
+	}
+	return writeFileSync(filename, cert, 0o400)
+}
+
+// readRevocation returns the validated revocation certificate for nametag, cert is nil if none exists
+func (id *HQ) readRevocation(nametag string) (r revocation, cert []byte, err error) {
+	filename, err := id.revocationFile(nametag)
+	if err != nil {
+		return r, nil, err
+	}
+	cert, err = os.ReadFile(filename)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return r, nil, nil
+	case err != nil:
+		return r, nil, fmt.Errorf("unable to read revocation [%s] [%w]", filename, err)
+	}
+	rev := &HQ{IO: IO{KeyStore: id.IO.KeyStore}}
+	if r, err = rev.decodeRevocation(cert); err != nil {
+		return r, nil, err
+	}
+	if string(rev.ID.TAG[:]) != nametag {
+		return r, nil, fmt.Errorf("%w [revocation %s is issued for %s]", ErrCorruptKey, filename, string(rev.ID.TAG[:]))
+	}
+	return r, cert, nil
+}
+
+// checkRevoked rejects signatures issued at or after the signer key revocation time,
+// signatures made before the revocation stay valid
+func (id *HQ) checkRevoked() error {
+	nametag := string(id.ID.TAG[:])
+	r, cert, err := id.readRevocation(nametag)
+	if err != nil || cert == nil {
+		return err
+	}
+	revoked, _ := strconv.ParseInt(r.tss, 10, 64)
+	signed, err := strconv.ParseInt(id.IO.TSS, 10, 64)
+	if err != nil || signed >= revoked {
+		id.IO.Trust = TrustRevoked
+		since, err := unix2RFC850(r.tss)
+		if err != nil {
+			since = r.tss
+		}
+		return fmt.Errorf("%w [%s is revoked since %s, reason: %s]", ErrUntrustedKey, nametag, since, r.reason)
+	}
+	return nil
+}
+
+// revoke issues a signed revocation certificate for the me identity, an already
+// existing revocation is kept [the earliest revocation time wins] and returned
+func (c *Config) revoke(ctx context.Context, reason string) (*HQ, []byte, error) {
+	id := NewHQ(c)
+	id.setPass(c)
+	id.IO.Signify = false
+	if err := id.readPublicKey(id.signAs()); err != nil {
+		return nil, nil, err
+	}
+	if r, cert, err := id.readRevocation(string(id.ID.TAG[:])); cert != nil || err != nil {
+		id.IO.TSS = r.tss
+		return id, cert, err
+	}
+	if err := id.prepSign("pending key revocation"); err != nil {
+		return nil, nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	r := revocation{tss: strconv.FormatInt(time.Now().Unix(), 10), reason: strings.Join(strings.Fields(reason), _space)}
+	id.IO.TSS = r.tss
+	id.IO.MSG = revokeMsg(r.reason)
+	if err := id.genSig(); err != nil {
+		return nil, nil, err
+	}
+	cert := id.encodeRevocation(r)
+	if err := id.writeRevocation(cert); err != nil {
+		return nil, nil, err
+	}
+	return id, cert, nil
+}
+
+// importRevocation validates the revocation certificate and stores it in the keystore
+func (c *Config) importRevocation(cert []byte) (*HQ, error) {
+	id := NewHQ(c)
+	r, err := id.decodeRevocation(cert)
+	if err != nil {
+		return nil, err
+	}
+	id.IO.TSS = r.tss
+	if _, exist, err := id.readRevocation(string(id.ID.TAG[:])); exist != nil || err != nil {
+		return id, err // first valid revocation wins, nothing to update
+	}
+	return id, id.writeRevocation(cert)
+}
+
+// runRevoke ...
+func (c *Config) runRevoke(ctx context.Context) error {
+	reason := strings.Join(os.Args[2:], _space)
+	if reason == "" {
+		reason = "unspecified"
+	}
+	_, cert, err := c.revoke(ctx, reason)
+	if err != nil {
+		return err
+	}
+	c.ui().outPlain(string(cert))
+	return nil
+}
+
+// reportRevocation ...
+func (id *HQ) reportRevocation() {
+	id.IO.Trust = TrustRevoked
+	id.IO.ReportTime = false
+	id.IO.ReportValid = true
+	id.report()
+}
@@ -0,0 +1,36 @@
+package hq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRevocationCutOff(t *testing.T) {
+	ctx := context.Background()
+	c := testConfig(t)
+	before := *c
+	before.FileName = testFile(t, "signed before the revocation")
+	if _, err := Sign(ctx, &before); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(1100 * time.Millisecond) // next time stamp
+	if _, err := Revoke(ctx, c, "test"); err != nil {
+		t.Fatal(err)
+	}
+	after := *c
+	after.FileName = testFile(t, "signed after the revocation")
+	if _, err := Sign(ctx, &after); err != nil {
+		t.Fatal(err)
+	}
+	before.FileName += _extSignature
+	if r, err := Verify(ctx, &before); err != nil || !r.Valid {
+		t.Fatalf("signature issued before the revocation: %v", err)
+	}
+	after.FileName += _extSignature
+	r, err := Verify(ctx, &after)
+	if !errors.Is(err, ErrUntrustedKey) || r == nil || r.Trust != TrustRevoked {
+		t.Fatalf("signature issued after the revocation: expected ErrUntrustedKey, got %v", err)
+	}
+}
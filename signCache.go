@@ -0,0 +1,130 @@
+package hq
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// const
+const (
+	_signCache        = ".hqCACHE"
+	_signCacheVersion = "1"
+	_signCacheRacy    = 2 * time.Second // entries changed that close to the sign start are never reused
+)
+
+// fileStat ...
+type fileStat struct {
+	size  int64
+	mtime int64
+	ino   uint64
+	ctime int64
+}
+
+// signCache holds the previous signed .hqMAP and its [size|mtime|inode|ctime] cache
+type signCache struct {
+	prev map[string]mapEntry // previous signed .hqMAP entries [nil: none]
+	stat map[string]fileStat // previous stat cache [nil: none|paranoid]
+	next map[string]fileStat // current stat records for the next cache
+}
+
+// loadSignCache loads the most recent .hqMAP of the target directory, the map is only
+// used if its primary signature validates under the own signing key, the stat cache
+// only if it belongs to this map
+func (id *HQ) loadSignCache(c *Config) *signCache {
+	sc := &signCache{next: make(map[string]fileStat)}
+	mapName, err := c.getMap()
+	if err != nil {
+		return sc
+	}
+	container, err := readFile(mapName + _extSignature)
+	if err != nil {
+		return sc
+	}
+	me := &HQ{IO: IO{KeyStore: id.IO.KeyStore}}
+	if err = me.readPublicKey(id.signAs()); err != nil {
+		return sc
+	}
+	prev := &HQ{IO: IO{KeyStore: id.IO.KeyStore}}
+	if err = prev.decodeSig(container); err != nil || prev.IO.IsExec {
+		return sc
+	}
+	if prev.ID.TAG != me.ID.TAG || prev.ID.KEY != me.ID.KEY {
+		return sc
+	}
+	if prev.IO.MSG, err = getMSGHash(mapName); err != nil || !prev.validateSig() {
+		return sc
+	}
+	data, err := decompressReadFile(mapName)
+	if err != nil {
+		return sc
+	}
+	if sc.prev, err = parseMap(data, id.IO.DirName); err != nil {
+		return sc
+	}
+	if !c.Paranoid {
+		sc.stat = readSignCache(fixPath(c.mapDir())+_signCache, filepath.Base(mapName), c.CodeReview)
+	}
+	return sc
+}
+
+// readSignCache returns the stat cache, nil if missing, defect or not created for mapName
+func readSignCache(filename, mapName string, code bool) map[string]fileStat {
+	data, err := decompressReadFile(filename)
+	if err != nil {
+		return nil
+	}
+	header, body, _ := bytes.Cut(data, []byte(_linefeedS))
+	if string(header) != "hqCACHE "+_signCacheVersion+_space+mapName+_space+strconv.FormatBool(code) {
+		return nil
+	}
+	stat := make(map[string]fileStat)
+	lines := strings.Split(string(body), _linefeedS)
+	for i := 0; i+1 < len(lines); i += 2 {
+		var st fileStat
+		if _, err := fmt.Sscan(lines[i+1], &st.size, &st.mtime, &st.ino, &st.ctime); err != nil {
+			return nil
+		}
+		stat[lines[i]] = st
+	}
+	return stat
+}
+
+// write stores the current stat records, racy [recently changed] entries are skipped
+func (sc *signCache) write(filename, mapName string, code bool, start time.Time) error {
+	var b bytes.Buffer
+	b.WriteString("hqCACHE " + _signCacheVersion + _space + mapName + _space + strconv.FormatBool(code) + _linefeedS)
+	racy := start.Add(-_signCacheRacy).UnixNano()
+	for name, st := range sc.next {
+		if st.ctime >= racy || st.mtime >= racy {
+			continue
+		}
+		fmt.Fprintf(&b, "%s\n%d %d %d %d\n", name, st.size, st.mtime, st.ino, st.ctime)
+	}
+	return compressWriteFile(filename, b.Bytes(), _compressedMapLevel, 0o660)
+}
+
+// lookup records the current stat of name and returns the previous map entry
+// if size, mtime, inode and ctime are unchanged [symlinks, sign errors are never reused]
+func (sc *signCache) lookup(name string) (mapEntry, fs.FileInfo, bool) {
+	fi, err := os.Lstat(name)
+	if err != nil || !fi.Mode().IsRegular() {
+		return mapEntry{}, nil, false
+	}
+	ino, ctime, ok := statID(fi)
+	if !ok {
+		return mapEntry{}, nil, false
+	}
+	st := fileStat{size: fi.Size(), mtime: fi.ModTime().UnixNano(), ino: ino, ctime: ctime}
+	sc.next[name] = st
+	if old, ok := sc.stat[name]; !ok || old != st {
+		return mapEntry{}, nil, false
+	}
+	e, ok := sc.prev[name]
+	return e, fi, ok && e.meta.err == ""
+}
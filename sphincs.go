@@ -1,6 +1,7 @@
 package hq
 
 import (
+	"errors"
 	"strconv"
 	"strings"
 	"time"
@@ -18,11 +19,12 @@ const (
 )
 
 // genSPHINCS ...
-func (id *HQ) genSphincs() {
+func (id *HQ) genSphincs() error {
 	// generate private key seeds
 	seed := cubetoken.Generate(&cubetoken.Config{
-		Progress:     true,
-		ForceNoColor: _forceNoColor,
+		Progress:     !id.IO.Silent,
+		ForceNoColor: !id.ui.color,
+		Out:          id.ui.e,
 		Memlimit:     _memlimit,
 		Parallel:     _parallel,
 		Layer:        _layer,
@@ -39,7 +41,7 @@ func (id *HQ) genSphincs() {
 	msg := blake3fix([]byte("NACHTS SIND ALLE BLAUEN KATZEN GRAU"))
 	sig := sphincs.Sign(id.IO.PRIVKEY, msg)
 	if !sphincs.Verify(id.ID.KEY, msg, sig) {
-		errExit("generate: sphincs keyset validation check failed")
+		return errors.New("generate: sphincs keyset validation check failed")
 	}
 
 	// generate nametag
@@ -54,13 +56,14 @@ func (id *HQ) genSphincs() {
 		c.WriteString(_closebracket)
 		id.IO.SIGNIFYPUB, err = signify.GeneratePKFromSeed(seed.SignifySeed).GetPubKeyFile(c.String())
 		if err != nil {
-			errExit("gen signify private key: " + err.Error())
+			return errors.New("gen signify private key: " + err.Error())
 		}
 	}
+	return nil
 }
 
 // genSIG ...
-func (id *HQ) genSig() {
+func (id *HQ) genSig() error {
 	var err error
 	msg := multiSliceAppendSEP(id.ID.OWNER[:], id.ID.TAG[:], []byte(id.IO.TSS), id.IO.MSG[:])
 	if id.IO.IsExec {
@@ -82,13 +85,14 @@ func (id *HQ) genSig() {
 		s.UntrustedComment = c.String()
 		if id.IO.SIGNIFYMSG != nil {
 			s.Raw = id.IO.SIGNIFYMSG
-		} else {
-			s.Raw = readFileErrExit(id.IO.FileName)
+		} else if s.Raw, err = readFile(id.IO.FileName); err != nil {
+			return err
 		}
 		if id.IO.SIGNIFYFILE, err = s.GetSigFile(signify.GeneratePKFromSeed(seed)); err != nil {
-			errExit("sign: signify: " + err.Error())
+			return errors.New("sign: signify: " + err.Error())
 		}
 	}
+	return nil
 }
 
 // validateSig ...
@@ -0,0 +1,35 @@
+//go:build darwin || freebsd || netbsd || dragonfly
+
+package hq
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// statID returns inode and ctime [unix nanoseconds] of fi
+func statID(fi fs.FileInfo) (ino uint64, ctime int64, ok bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return uint64(st.Ino), st.Ctimespec.Nano(), true
+}
+
+// statDev returns device and inode of fi
+func statDev(fi fs.FileInfo) (dev, ino uint64, ok bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return uint64(st.Dev), uint64(st.Ino), true
+}
+
+// statOwner returns uid and gid of fi
+func statOwner(fi fs.FileInfo) (uid, gid uint32, ok bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return st.Uid, st.Gid, true
+}
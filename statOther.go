@@ -0,0 +1,20 @@
+//go:build !linux && !openbsd && !darwin && !freebsd && !netbsd && !dragonfly
+
+package hq
+
+import "io/fs"
+
+// statID is not available, incremental sign falls back to full rehash
+func statID(_ fs.FileInfo) (ino uint64, ctime int64, ok bool) {
+	return 0, 0, false
+}
+
+// statDev is not available, no file system boundary or symlink loop detection
+func statDev(_ fs.FileInfo) (dev, ino uint64, ok bool) {
+	return 0, 0, false
+}
+
+// statOwner is not available, the .hqMAP records no ownership
+func statOwner(_ fs.FileInfo) (uid, gid uint32, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,306 @@
+package hq
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Trust level of a public key [NameTAG] within the keystore trust database
+type Trust int
+
+// const
+const (
+	// TrustUnknown key is not listed [implicit trust without trust database]
+	TrustUnknown Trust = iota
+	// TrustMarginal key is accepted, but reported as marginal
+	TrustMarginal
+	// TrustTrusted key is fully trusted
+	TrustTrusted
+	// TrustExpired key trust has expired, signatures are rejected
+	TrustExpired
+	// TrustRevoked key is revoked, signatures are rejected
+	TrustRevoked
+)
+
+// const
+const (
+	_trustDB        = ".trust"
+	_allowedSigners = ".allowed_signers"
+)
+
+// String ...
+func (t Trust) String() string {
+	switch t {
+	case TrustMarginal:
+		return "marginal"
+	case TrustTrusted:
+		return "trusted"
+	case TrustExpired:
+		return "expired"
+	case TrustRevoked:
+		return "revoked"
+	}
+	return "unknown"
+}
+
+// parseTrust ...
+func parseTrust(in string) (Trust, error) {
+	for t := TrustUnknown; t <= TrustRevoked; t++ {
+		if t.String() == in {
+			return t, nil
+		}
+	}
+	return TrustUnknown, errors.New("unknown trust level [" + in + "], use: trusted|marginal|expired|revoked|unknown")
+}
+
+// trustEntry ...
+type trustEntry struct {
+	tag     string
+	level   Trust
+	expires int64 // unix seconds, 0 == never
+}
+
+// trustDB ...
+type trustDB struct {
+	exists  bool
+	entries []trustEntry
+}
+
+// level returns the effective [expiry checked] trust level for nametag
+func (db *trustDB) level(nametag string) Trust {
+	for _, e := range db.entries {
+		if e.tag != nametag {
+			continue
+		}
+		if e.expires != 0 && e.expires < time.Now().Unix() && e.level != TrustRevoked {
+			return TrustExpired
+		}
+		return e.level
+	}
+	return TrustUnknown
+}
+
+// set ...
+func (db *trustDB) set(nametag string, level Trust, expires int64) {
+	db.entries = slices.DeleteFunc(db.entries, func(e trustEntry) bool { return e.tag == nametag })
+	if level != TrustUnknown {
+		db.entries = append(db.entries, trustEntry{tag: nametag, level: level, expires: expires})
+	}
+}
+
+// readTrustDB ...
+func (id *HQ) readTrustDB() (*trustDB, error) {
+	keystore, err := id.keyStore()
+	if err != nil {
+		return nil, err
+	}
+	db := &trustDB{}
+	data, err := os.ReadFile(keystore + _trustDB)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return db, nil
+	case err != nil:
+		return nil, fmt.Errorf("unable to read trust database [%s] [%w]", keystore+_trustDB, err)
+	}
+	db.exists = true
+	lines, err := readConfigLines(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read trust database [%s] [%w]", keystore+_trustDB, err)
+	}
+	for _, l := range lines {
+		n, f := l.n, strings.Fields(l.line)
+		if len(f) < 2 || len(f) > 3 {
+			return nil, fmt.Errorf("trust database [%s] line %d: syntax error", keystore+_trustDB, n)
+		}
+		e := trustEntry{tag: f[0]}
+		if e.level, err = parseTrust(f[1]); err != nil {
+			return nil, fmt.Errorf("trust database [%s] line %d: %w", keystore+_trustDB, n, err)
+		}
+		if len(f) == 3 {
+			if e.expires, err = strconv.ParseInt(f[2], 10, 64); err != nil {
+				return nil, fmt.Errorf("trust database [%s] line %d: invalid expire time stamp", keystore+_trustDB, n)
+			}
+		}
+		db.entries = append(db.entries, e)
+	}
+	return db, nil
+}
+
+// writeTrustDB ...
+func (id *HQ) writeTrustDB(db *trustDB) error {
+	keystore, err := id.keyStore()
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	b.WriteString("# hq trust database: <NameTAG> <trusted|marginal|expired|revoked> <opt:expire unix time stamp>\n")
+	for _, e := range db.entries {
+		b.WriteString(e.tag + _space + e.level.String())
+		if e.expires != 0 {
+			b.WriteString(_space + strconv.FormatInt(e.expires, 10))
+		}
+		b.WriteString(_linefeedS)
+	}
+	if err := os.MkdirAll(keystore[:len(keystore)-1], 0o700); err != nil {
+		return fmt.Errorf("unable to create [%s] [%w]", keystore, err)
+	}
+	return writeFileSync(keystore+_trustDB, b.Bytes(), 0o600)
+}
+
+// allowedSigners returns the NameTAGs of the last allowed signers rule matching path
+// [or any of its parent directories], ok is false if no rule matches
+func (id *HQ) allowedSigners(path string) (tags []string, ok bool, err error) {
+	keystore, err := id.keyStore()
+	if err != nil {
+		return nil, false, err
+	}
+	data, err := os.ReadFile(keystore + _allowedSigners)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, false, nil
+	case err != nil:
+		return nil, false, fmt.Errorf("unable to read allowed signers [%s] [%w]", keystore+_allowedSigners, err)
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		return nil, false, err
+	}
+	lines, err := readConfigLines(data)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to read allowed signers [%s] [%w]", keystore+_allowedSigners, err)
+	}
+	for _, l := range lines {
+		n, line := l.n, l.line
+		pattern, signers, found := strings.Cut(line, _space)
+		if !found {
+			return nil, false, fmt.Errorf("allowed signers [%s] line %d: syntax error", keystore+_allowedSigners, n)
+		}
+		for p := path; ; p = filepath.Dir(p) {
+			if match, _ := filepath.Match(filepath.Clean(pattern), p); match {
+				tags, ok = strings.Split(strings.ReplaceAll(strings.TrimSpace(signers), _space, _empty), ","), true
+				break
+			}
+			if p == filepath.Dir(p) {
+				break
+			}
+		}
+	}
+	return tags, ok, nil
+}
+
+// checkTrust enforces key revocations, trust database and allowed signers policy for the signer of path
+func (id *HQ) checkTrust(path string) error {
+	if err := id.checkRevoked(); err != nil {
+		return err
+	}
+	db, err := id.readTrustDB()
+	if err != nil {
+		return err
+	}
+	nametag := string(id.ID.TAG[:])
+	id.IO.Trust = db.level(nametag)
+	switch id.IO.Trust {
+	case TrustRevoked, TrustExpired:
+		return fmt.Errorf("%w [%s is %s]", ErrUntrustedKey, nametag, id.IO.Trust)
+	case TrustUnknown:
+		if db.exists && !id.IO.KeyPinned {
+			return fmt.Errorf("%w [%s is not listed in trust database]", ErrUntrustedKey, nametag)
+		}
+	}
+	if path == "" {
+		return nil
+	}
+	tags, ok, err := id.allowedSigners(path)
+	switch {
+	case err != nil:
+		return err
+	case ok && !slices.Contains(tags, nametag):
+		return fmt.Errorf("%w [%s is not an allowed signer for %s]", ErrUntrustedKey, nametag, path)
+	}
+	return nil
+}
+
+// trustVerified applies checkTrust to a cryptographically valid signature and reports
+func (id *HQ) trustVerified(path string) error {
+	if err := id.checkTrust(path); err != nil {
+		id.IO.ReportValid = false
+		id.report()
+		return err
+	}
+	id.IO.ReportValid = true
+	id.report()
+	return nil
+}
+
+// runTrust lists [hq trust] or sets [hq trust <nametag> <level> <opt:expire>] key trust levels
+func (c *Config) runTrust() error {
+	id := NewHQ(c)
+	db, err := id.readTrustDB()
+	if err != nil {
+		return err
+	}
+	args := os.Args[2:]
+	if len(args) == 0 {
+		for _, e := range db.entries {
+			expires := _empty
+			if e.expires != 0 {
+				expires = " [expires: " + time.Unix(e.expires, 0).Format(time.RFC850) + "]"
+			}
+			id.ui.out(e.tag + _space + padTrust(db.level(e.tag).String()) + expires)
+		}
+		return nil
+	}
+	if len(args) < 2 || len(args) > 3 {
+		return errors.New("usage: hq trust <nametag> <trusted|marginal|expired|revoked|unknown> <opt:expire unix time stamp>")
+	}
+	level, err := parseTrust(args[1])
+	if err != nil {
+		return err
+	}
+	var expires int64
+	if len(args) == 3 {
+		if expires, err = strconv.ParseInt(args[2], 10, 64); err != nil {
+			return errors.New("invalid expire unix time stamp [" + args[2] + "]")
+		}
+	}
+	if err = id.readPublicKey(args[0]); err != nil {
+		return err
+	}
+	db.set(string(id.ID.TAG[:]), level, expires)
+	return id.writeTrustDB(db)
+}
+
+// padTrust ...
+func padTrust(in string) string {
+	for len(in) < 8 {
+		in += _space
+	}
+	return in
+}
+
+// configLine ...
+type configLine struct {
+	n    int
+	line string
+}
+
+// readConfigLines returns all non-empty, non-comment lines in order
+func readConfigLines(data []byte) (lines []configLine, err error) {
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		lines = append(lines, configLine{n: n, line: line})
+	}
+	return lines, s.Err()
+}
@@ -0,0 +1,69 @@
+package hq
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadTrustDBLongLine(t *testing.T) {
+	c := testConfig(t)
+	db := "# trust\n" + strings.Repeat("A", bufio.MaxScanTokenSize) + " trusted\n"
+	if err := os.WriteFile(filepath.Join(c.KeyRing, _trustDB), []byte(db), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewHQ(c).readTrustDB(); !errors.Is(err, bufio.ErrTooLong) {
+		t.Fatalf("expected bufio.ErrTooLong, got %v", err)
+	}
+}
+
+func TestTrustExpiry(t *testing.T) {
+	ctx := context.Background()
+	c := testConfig(t)
+	c.FileName = testFile(t, "trust expiry")
+	if _, err := Sign(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	id := NewHQ(c)
+	if err := id.readPublicKey(_me); err != nil {
+		t.Fatal(err)
+	}
+	tag := string(id.ID.TAG[:])
+	v := *c
+	v.FileName += _extSignature
+	now := time.Now().Unix()
+	for _, tc := range []struct {
+		tag     string
+		level   Trust
+		expires int64
+		want    Trust
+		err     error
+	}{
+		{tag, TrustTrusted, 0, TrustTrusted, nil},
+		{tag, TrustTrusted, now + 3600, TrustTrusted, nil},
+		{tag, TrustMarginal, now + 3600, TrustMarginal, nil},
+		{tag, TrustTrusted, now - 3600, TrustExpired, ErrUntrustedKey},
+		{tag, TrustRevoked, now - 3600, TrustRevoked, ErrUntrustedKey},
+		{tag, TrustExpired, 0, TrustExpired, ErrUntrustedKey},
+		{"AAAAAA-AA-AAAAAA-AA-AAAAAAAAAA", TrustTrusted, 0, TrustUnknown, ErrUntrustedKey}, // not listed
+	} {
+		db := &trustDB{}
+		db.set(tc.tag, tc.level, tc.expires)
+		if err := id.writeTrustDB(db); err != nil {
+			t.Fatal(err)
+		}
+		r, err := Verify(ctx, &v)
+		if !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+			t.Errorf("%s until %d: expected %v, got %v", tc.level, tc.expires, tc.err, err)
+			continue
+		}
+		if r != nil && r.Trust != tc.want {
+			t.Errorf("%s until %d: trust %s, want %s", tc.level, tc.expires, r.Trust, tc.want)
+		}
+	}
+}
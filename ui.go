@@ -2,6 +2,8 @@ package hq
 
 import (
 	"encoding/base64"
+	"errors"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -9,6 +11,7 @@ import (
 	"time"
 
 	"golang.org/x/crypto/argon2"
+	"golang.org/x/term"
 )
 
 var (
@@ -28,12 +31,27 @@ const (
 	_owner     = "# Owner ID      : "
 	_tag       = "# Name TAG      : "
 	_signifyid = "# SignifyPubKey : "
+	_trust     = "# Trust Level   : "
 	_file      = "# File Name     : "
 	_ts        = "# Time Stamp    : "
 	_total     = "# Time needed   : "
 	_ffail     = "# Files FAIL    : "
 	_fok       = "# Files OK      : "
 	_fnew      = "# Files NEW     : "
+	_freused   = "# Files REUSED  : "
+	_fchanged  = "# Files CHANGED : "
+	_fremoved  = "# Files REMOVED : "
+	_fskipped  = "# Files SKIPPED : "
+	_fhashed   = "# Files HASHED  : "
+	_frenamed  = "# Files RENAMED : "
+	_frestored = "# File RESTORED : "
+	_fwatch    = "# Watching Dir  : "
+	_dfrom     = "# Diff From     : "
+	_dto       = "# Diff To       : "
+	_dadded    = "# File ADDED    : "
+	_dremoved  = "# File REMOVED  : "
+	_dmodified = "# File MODIFIED : "
+	_drenamed  = "# File RENAMED  : "
 	_files     = "# Files Total   : "
 	_stat      = "# Status        : "
 	_errc      = "# Error Code    : "
@@ -49,230 +67,337 @@ const (
 	_errChashOK        = "COMPILED CODE WILL BE OK [CODEREVIEW HASH NOT CHANGED]"
 	_errChashFail      = "CODE MODIFIED [CODEREVIEW HASH MISSMATCH]"
 	_errChashUnable    = "UNABLE TO VERIFY SIGNED CODE HASH"
+	_errFileType       = "FILE TYPE CHANGED"
+	_errFileSymlink    = "SYMLINK TARGET CHANGED"
+	_errFileMode       = "FILE MODE CHANGED [PERMISSION]"
+	_errFileOwner      = "FILE OWNER CHANGED [UID:GID]"
+	_errFileNotSigned  = "FILE NOT SIGNED [SIGN TIME ERROR]"
 	_errIntParser      = "HQ INTERAL PARSER ERROR: UNKNOWN OPTION"
 	_errOwnerSize      = "no support for UserIDs with less than 6 or more than 64 characters"
 	_errOwnerCharacter = "no support for UserIDs with the equal sign (=)"
 )
 
-func version() {
-	out("hq ( VERSION: " + _semver + " )" + " ( COMMIT: " + _commit + " )" + " ( BUILD DATE: " + _builddate + " )")
+func (u *ui) version() {
+	u.out("hq ( VERSION: " + _semver + " )" + " ( COMMIT: " + _commit + " )" + " ( BUILD DATE: " + _builddate + " )")
 }
 
-func syntaxUI() {
-	out("usage: hq  <action> <opt:target> <opt:timestamp|exec-parameter>")
+func (u *ui) syntaxUI() {
+	u.out("usage: hq  <action> <opt:target> <opt:timestamp|exec-parameter>")
 }
 
-func usage() {
-	out("\naction:")
-	out("[s]ign      sign mode for <target>")
-	out("[c]ode      sign mode for <target>, include additional code-review hashes")
-	out("[v]erify    verify mode for <target>")
-	out("[r]un       run .hqx exec container")
-	out("[g]enerate  generate new hq id [or: re-produce public key]")
-	out("[u]nlock    unlock id [raw sphincs key]")
-	out("[l]ock      lock [remove] cached raw sphincs key")
-	out("[p]wd       generate hq id and <target> specific password")
-	out("[x]pwd      generate hq id and <target> specific legacy password")
-	out("[t]est      verify crypto functions via hard-wired test vector suite")
-	out("[b]ench     benchmark")
-	out("[h]elp      show help\n")
-	out("<hqx>|<hqs>|<dir>|<pipe>|<exec> - object typ will pick the action\n")
+func (u *ui) usage() {
+	u.out("\naction:")
+	u.out("[s]ign      sign mode for <target>")
+	u.out("[c]ode      sign mode for <target>, include additional code-review hashes")
+	u.out("[v]erify    verify mode for <target>")
+	u.out("[r]un       run .hqx exec container")
+	u.out("[g]enerate  generate new hq id [or: re-produce public key]")
+	u.out("export      export armored public key <opt:nametag> [default: me]")
+	u.out("import      import armored public key or revocation <file|pipe> [signature verified]")
+	u.out("trust       list trust database or set <nametag> <trusted|marginal|expired|revoked|unknown> <opt:expire>")
+	u.out("revoke      issue signed revocation certificate for me id <opt:reason>")
+	u.out("cosign      add signature of me [--as] id to an existing <.hqs|dir>")
+	u.out("id          manage identities <list|use <nametag>|show <opt:nametag>|rm <nametag>>")
+	u.out("diff        compare two signed .hqMAP snapshots <dir> <timestamp> <timestamp>")
+	u.out("maps list   show all .hqMAP snapshots of <dir> [timestamp, signer, files, signature]")
+	u.out("watch       verify <dir>, then report every change against its signed .hqMAP [inotify, ctrl-c ends]")
+	u.out("[u]nlock    unlock id [raw sphincs key]")
+	u.out("[l]ock      lock [remove] cached raw sphincs key")
+	u.out("[p]wd       generate hq id and <target> specific password")
+	u.out("[x]pwd      generate hq id and <target> specific legacy password")
+	u.out("[t]est      verify crypto functions via hard-wired test vector suite")
+	u.out("[b]ench     benchmark")
+	u.out("[h]elp      show help\n")
+	u.out("<hqx>|<hqs>|<dir>|<pipe>|<exec> - object typ will pick the action\n")
+	u.out("options:")
+	u.out("--key <file>     verify against this public key file only [no keystore lookup]")
+	u.out("--keyring <dir>  use <dir> as keystore [default: ~/.hq]")
+	u.out("--as <nametag>   sign with this identity [default: me]")
+	u.out("--threshold <k>  verify requires k distinct trusted signers [multi-signature]")
+	u.out("--paranoid       dir sign: rehash every file, ignore the [size|mtime|inode|ctime] cache")
+	u.out("--quick          dir verify: only hash files with changed [size|mtime|mode]")
+	u.out("--archive        sign|verify the members of a tar[.gz|.zst] or zip archive [<archive>.hqMAP.zst]")
+	u.out("--map-out <dir>  dir|archive sign: write .hqMAP, .hqs and .hqCACHE into <dir> [default: the signed directory]")
+	u.out("--map-in <dir>   dir|archive verify: read the .hqMAP snapshots from <dir>")
+	u.out("--only <pat>     dir verify|watch: only hash and report entries matching <path|glob> [repeatable]")
+	u.out("--chain          dir verify: verify the hash chain of all .hqMAP snapshots [gaps|forks]")
+	u.out("--exclude <pat>  dir sign: skip files matching <pat> [.hqignore syntax, repeatable]")
+	u.out("--include <pat>  dir sign: re-include files matching <pat> [overrides .hqignore]")
+	u.out("--follow         dir sign: follow symbolic links to directories [loop safe, recorded in the .hqMAP]")
+	u.out("--one-fs         dir sign: do not descend into other file systems [mount points, recorded in the .hqMAP]")
+	u.out("--max-depth <n>  dir sign: only sign entries up to depth n [1: top level only, recorded in the .hqMAP]")
+	u.out("--no-special     dir sign: skip special files [pipe|socket|device, recorded in the .hqMAP]")
+	u.out("--walk-threads <n> dir sign|verify|watch: parallel directory reads [default: number of cpu cores]")
+	u.out("--keep-last <n>  dir sign: keep the n most recent .hqMAPs [default: 10]")
+	u.out("--keep-daily <n> dir sign: keep the most recent .hqMAP of the last n days [default: 7]")
+	u.out("--keep-weekly <n> dir sign: keep the most recent .hqMAP of the last n weeks [default: 4]")
+	u.out("--json           machine readable report [dir verify: NDJSON per-file events + final record]\n")
 }
 
-func examples() {
-	out("EXAMPLES")
-	out(" hq generate [generate new hq identity]")
-	out(" hq sign mybackup.tar.zst")
-	out(" hq verify mybackup.tar.zst.hqs")
-	out(" hq mybackup.tar.zst.hqs")
-	out(" hq sign myscript.sh [.sh -> .hqx]")
-	out(" hq verify myscript.hqx [display script content]")
-	out(" hq run myscript.hqx")
-	out(" hq myscript.hqx")
-	out(" ./myscript.hqx")
-	out(" cat myscript.hqx | hq")
-	out(" hq myscript.sh  [run myscript.sh]")
-	out(" hq v . 1633 [verify most recent hqMAP starting 1633* ]")
-	out(" hq  sign /usr/store")
-	out(" hq  s . [short form for sign map in current dir]")
-	out(" hq  v [verify most recent .hqMAP in . ]\n")
-	out(" ./myfile.hqs [verify signature of myfile]\n")
+func (u *ui) examples() {
+	u.out("EXAMPLES")
+	u.out(" hq generate [generate new hq identity]")
+	u.out(" hq sign mybackup.tar.zst")
+	u.out(" hq verify mybackup.tar.zst.hqs")
+	u.out(" hq mybackup.tar.zst.hqs")
+	u.out(" hq sign myscript.sh [.sh -> .hqx]")
+	u.out(" hq verify myscript.hqx [display script content]")
+	u.out(" hq run myscript.hqx")
+	u.out(" hq myscript.hqx")
+	u.out(" ./myscript.hqx")
+	u.out(" cat myscript.hqx | hq")
+	u.out(" hq myscript.sh  [run myscript.sh]")
+	u.out(" hq v . 1633 [verify most recent hqMAP starting 1633* ]")
+	u.out(" hq  sign /usr/store")
+	u.out(" hq  s . [short form for sign map in current dir]")
+	u.out(" hq  v [verify most recent .hqMAP in . ]")
+	u.out(" hq maps list /usr/store")
+	u.out(" hq verify --chain /usr/store")
+	u.out(" hq verify /usr/store --only etc --only 'bin/*.sh'")
+	u.out(" hq watch --json /usr/store")
+	u.out(" hq sign --one-fs --no-special --max-depth 3 /")
+	u.out(" hq sign --archive release.tar.zst")
+	u.out(" hq verify --archive release.tar.zst")
+	u.out(" hq export > release.pub")
+	u.out(" hq import release.pub")
+	u.out(" hq verify --key ./release.pub mybackup.tar.zst.hqs")
+	u.out(" hq verify --keyring ./keys mybackup.tar.zst.hqs")
+	u.out(" hq revoke key compromised > revoke.asc")
+	u.out(" hq cosign mybackup.tar.zst.hqs")
+	u.out(" hq verify --threshold 2 mybackup.tar.zst.hqs")
+	u.out(" hq id use C5HFAZ-62-MJMOJJ-3R-YABSRWLLKH")
+	u.out(" hq sign --as C5HFAZ-62-MJMOJJ-3R-YABSRWLLKH mybackup.tar.zst")
+	u.out(" hq trust C5HFAZ-62-MJMOJJ-3R-YABSRWLLKH trusted [policy: ~/.hq/.trust ~/.hq/.allowed_signers]\n")
+	u.out(" ./myfile.hqs [verify signature of myfile]\n")
 }
 
-func env() {
-	out("ENV")
-	out(" FORCE_COLOR=true          color terminal output")
-	out(" " + _envHQSignify + "=true       generate additional OpenBSD signify compatible .sig signatures")
-	out(" " + _envHQSigOnly + "=true          to sign executeables as normal .hqs signatures only")
-	out(" " + _envHQMapOnly + "=true          to generate .hqMAP files without signature")
-	out(" " + _envHQMapClean + "=true         to remove all existing .hqMAP[s] on <target>")
-	out(" " + _envHQOWNER + "                  set owner for generate operations [batch mode]\n")
-	out(" [-> all env settings can be [disabled|overruled] via compile time flags!\n")
+func (u *ui) env() {
+	u.out("ENV")
+	u.out(" FORCE_COLOR=true          color terminal output")
+	u.out(" " + _envHQSignify + "=true       generate additional OpenBSD signify compatible .sig signatures")
+	u.out(" " + _envHQSigOnly + "=true          to sign executeables as normal .hqs signatures only")
+	u.out(" " + _envHQMapOnly + "=true          to generate .hqMAP files without signature")
+	u.out(" " + _envHQMapClean + "=true         to remove all previous .hqMAP[s] on <target> [ignore the retention policy]")
+	u.out(" " + _envHQOWNER + "                  set owner for generate operations [batch mode]\n")
+	u.out(" [-> all env settings can be [disabled|overruled] via compile time flags!\n")
 }
 
-func help() {
-	// version()
-	syntaxUI()
-	usage()
-	env()
-	examples()
+func (u *ui) help() {
+	// u.version()
+	u.syntaxUI()
+	u.usage()
+	u.env()
+	u.examples()
 }
 
 func (id *HQ) reportPwd(c *Config) {
-	if id.IO.ColorUI {
-		gON, yON, cOFF = _Green, _Yelllow, _Off
-	}
-	defer outPlain(cOFF)
-	outPlain(yON + "# PASSWORD FOR SERVICE : " + c.PwdService)
+	u := id.ui
+	defer u.outPlain(u.cOFF)
+	u.outPlain(u.yON + "# PASSWORD FOR SERVICE : " + c.PwdService)
 	if c.PwdComplex {
 		pwd := argon2d(id.IO.SIG[8192:], sha2(append([]byte(_hashKMAC), id.IO.SIG[:8192]...)))
-		out(" -> " + gON + base64.StdEncoding.EncodeToString(sha3(sha2(pwd)))[:32])
+		u.out(" -> " + u.gON + base64.StdEncoding.EncodeToString(sha3(sha2(pwd)))[:32])
 		return
 	}
 	pwd := argon2d(id.IO.SIG[4096:], sha2(append([]byte(_hashKMAC), id.IO.SIG[:4096]...)))
-	out(" [legacy mode] -> " + gON + base64.StdEncoding.EncodeToString(sha2(sha3(pwd)))[:16])
+	u.out(" [legacy mode] -> " + u.gON + base64.StdEncoding.EncodeToString(sha2(sha3(pwd)))[:16])
 }
 
 func (id *HQ) reportDir() {
 	if id.IO.Silent {
 		return
 	}
+	u, add := id.ui, ""
 	if id.IO.ReportValid {
-		add = _valid
-		if id.IO.ColorUI {
-			add = _Valid
-		}
+		add = u.valid
 	}
-	if id.IO.ColorUI {
-		aON, rON, bON, gON, eON, cOFF = _Red, _Red, _Blue, _Green, _Grey, _Off
-		files, ffail, fok, total = _Files, _Ffail, _Fok, _Total
-		defer outPlain(cOFF)
+	aON := u.aON
+	if u.color {
+		defer u.outPlain(u.cOFF)
 		if id.IO.FilesFail == 0 {
-			aON = _Green
+			aON = u.gON
 		}
 	}
 	if id.IO.FilesNew != 0 {
 		switch {
 		case id.IO.FilesNew > 2:
-			out("\n" + fnew + rON + strconv.FormatUint(id.IO.FilesNew, 10) + cOFF)
+			u.out("\n" + u.fnew + u.rON + strconv.FormatUint(id.IO.FilesNew, 10) + u.cOFF)
 		default:
-			out("\n" + fnew + gON + strconv.FormatUint(id.IO.FilesNew, 10) + cOFF)
+			u.out("\n" + u.fnew + u.gON + strconv.FormatUint(id.IO.FilesNew, 10) + u.cOFF)
 		}
 	}
-	out(ffail + aON + strconv.FormatUint(id.IO.FilesFail, 10) + cOFF)
-	out(fok + gON + padstring(strconv.FormatUint(id.IO.FilesTotal-id.IO.FilesFail, 10)) + cOFF + add)
-	out(files + bON + strconv.FormatUint(id.IO.FilesTotal, 10) + cOFF)
-	out(total + eON + time.Since(id.IO.Start).String() + cOFF)
+	u.out(u.ffail + aON + strconv.FormatUint(id.IO.FilesFail, 10) + u.cOFF)
+	u.out(u.fok + u.gON + padstring(strconv.FormatUint(id.IO.FilesTotal-id.IO.FilesFail, 10)) + u.cOFF + add)
+	u.out(u.files + u.bON + strconv.FormatUint(id.IO.FilesTotal, 10) + u.cOFF)
+	if id.IO.Quick {
+		u.out(u.fskipped + u.bON + strconv.FormatUint(id.IO.FilesSkipped, 10) + u.cOFF + " [size|mtime|mode unchanged]")
+		u.out(u.fhashed + u.bON + strconv.FormatUint(id.IO.FilesTotal-id.IO.FilesFail-id.IO.FilesSkipped, 10) + u.cOFF)
+	}
+	u.out(u.total + u.eON + time.Since(id.IO.Start).String() + u.cOFF)
+}
+
+// reportDelta reports the dir sign delta against the previous signed .hqMAP [NEW: see reportDir]
+func (id *HQ) reportDelta() {
+	if id.IO.Silent {
+		return
+	}
+	u := id.ui
+	label := [3]string{_freused, _fchanged, _fremoved}
+	if u.color {
+		for i := range label {
+			label[i] = _Yelllow + label[i] + _Off
+		}
+		defer u.outPlain(u.cOFF)
+	}
+	for i, n := range [3]uint64{id.IO.FilesReused, id.IO.FilesChanged, id.IO.FilesRemoved} {
+		u.out(label[i] + u.bON + strconv.FormatUint(n, 10) + u.cOFF)
+	}
 }
 
 func (id *HQ) report() {
 	if id.IO.Silent {
 		return
 	}
-	if id.IO.ColorUI {
-		bON, cON, mON, wON, rON, cOFF = _Blue, _Cyan, _Magenta, _White, _Grey, _Off
-		owner, tag, signifyid, total, ts, file = _Owner, _Tag, _Signifyid, _Total, _Ts, _File
-		defer outPlain(cOFF)
+	u, add := id.ui, ""
+	if u.color {
+		defer u.outPlain(u.cOFF)
 	}
 	if id.IO.ReportValid {
-		add = _valid
-		if id.IO.ColorUI {
-			add = _Valid
-		}
+		add = u.valid
 	}
 	if id.IO.ReportID {
-		out(owner + cON + unpad(id.ID.OWNER) + cOFF)
-		out(tag + mON + padstring(string(id.ID.TAG[:])) + cOFF + add)
+		u.out(u.owner + u.cON + unpad(id.ID.OWNER) + u.cOFF)
+		u.out(u.tag + u.mON + padstring(string(id.ID.TAG[:])) + u.cOFF + add)
 		if id.IO.SIGNIFYPUB != nil {
 			sig := strings.Split(string(id.IO.SIGNIFYPUB), _linefeedS)
-			out(signifyid + mON + padstring(sig[1]+cOFF))
+			u.out(u.signifyid + u.mON + padstring(sig[1]+u.cOFF))
+		}
+		if id.IO.Trust != TrustUnknown {
+			u.out(u.trust + u.mON + id.IO.Trust.String() + u.cOFF)
 		}
 	}
 	if id.IO.FileName != "" && id.IO.FileName != "." {
-		out(file + bON + padstring(id.IO.FileName) + cOFF + add)
+		u.out(u.file + u.bON + padstring(id.IO.FileName) + u.cOFF + add)
 	}
 	if id.IO.TSS != "" {
-		out(ts + wON + padstring(unix2RFC850(id.IO.TSS)+" ["+id.IO.TSS+"]") + add)
+		t, err := unix2RFC850(id.IO.TSS)
+		if err != nil {
+			t = err.Error()
+		}
+		u.out(u.ts + u.wON + padstring(t+" ["+id.IO.TSS+"]") + add)
 	}
 	if id.IO.ReportTime && _reportTime {
 		if id.IO.End.IsZero() {
 			id.IO.End = time.Now()
 		}
-		out(total + rON + id.IO.End.Sub(id.IO.Start).String() + cOFF)
+		u.out(u.total + u.eON + id.IO.End.Sub(id.IO.Start).String() + u.cOFF)
 	}
 }
 
-func getOwner() [64]byte {
-	var o string
-	if o = getOwnerEnv(); o == "" {
-		if getColorUI() {
-			owner, cOFF = _Owner+_Cyan, _Off
-			defer outPlain(cOFF)
+func (u *ui) getOwner() ([64]byte, error) {
+	o, err := getOwnerEnv()
+	if err != nil {
+		return [64]byte{}, err
+	}
+	if o == "" {
+		if !term.IsTerminal(0) {
+			return [64]byte{}, errors.New("owner id required, but no terminal available [set " + _envHQOWNER + "]")
 		}
+		defer u.prompt(u.cOFF)
 		for {
-			o = readLine(owner)
-			outPlain(cOFF)
+			if o, err = u.readLine(u.owner + u.cON); err != nil {
+				return [64]byte{}, err
+			}
+			u.prompt(u.cOFF)
 			l := len(o)
 			switch {
 			case l < 6 || l > 64:
-				errOut(_errOwnerSize)
+				u.errOut(_errOwnerSize)
 				continue
 			case strings.Contains(o, "="):
-				errOut(_errOwnerCharacter)
+				u.errOut(_errOwnerCharacter)
 				continue
 			}
 			break
 		}
 	}
-	return pad(o)
+	return pad(o), nil
 }
 
-func passEntryHash(name string, masked, repeat bool) [64]byte {
-	if getColorUI() {
-		bON, cOFF = _Blue, _Off
-		defer outPlain(cOFF)
-	}
+func (u *ui) passEntryHash(name string, masked, repeat bool) ([64]byte, error) {
+	defer u.prompt(u.cOFF)
 	for {
-		p := readPassword(bON+"# Passphrase "+name+": ", masked)
-		outPlain(cOFF)
+		p, err := u.readPassword(u.bON+"# Passphrase "+name+": ", masked)
+		if err != nil {
+			return [64]byte{}, err
+		}
+		u.prompt(u.cOFF)
 		switch {
 		case len(p) < _minimumPasswordLen:
-			out("  Please enter a Passphrase with at least " + strconv.Itoa(_minimumPasswordLen) + " characters")
+			u.prompt("  Please enter a Passphrase with at least " + strconv.Itoa(_minimumPasswordLen) + " characters" + _linefeedS)
 			continue
 		case repeat:
-			p2 := readPassword(bON+"# Repeat     "+name+": ", masked)
-			outPlain(cOFF)
+			p2, err := u.readPassword(u.bON+"# Repeat     "+name+": ", masked)
+			if err != nil {
+				return [64]byte{}, err
+			}
+			u.prompt(u.cOFF)
 			if p != p2 {
-				out("  Passwords do not match! Please try again!")
+				u.prompt("  Passwords do not match! Please try again!" + _linefeedS)
 				continue
 			}
 		}
-		return hashWrap512([]byte(p))
+		return hashWrap512([]byte(p)), nil
 	}
 }
 
-func errOut(m string) {
-	if getColorUI() {
-		aON, cOFF = _Red, _Off
+func (id *HQ) reportSigners(required int) {
+	if id.IO.Silent {
+		return
 	}
-	out(aON + "ERROR: " + m + cOFF)
+	u, aON := id.ui, id.ui.aON
+	if id.IO.Signers >= required {
+		aON = u.gON
+	}
+	u.out(u.stat + aON + "SIGNERS: " + strconv.Itoa(id.IO.Signers) + " OF " + strconv.Itoa(required) + " REQUIRED" + u.cOFF)
 }
 
+func (id *HQ) reportSigFail() {
+	if id.IO.Silent {
+		return
+	}
+	id.ui.out(id.ui.stat + "SIGNATURE VALIDATION: " + id.ui.fail)
+}
+
+// cliReport reports err [if any] and returns the cli status
+func cliReport(err error) bool {
+	if err != nil {
+		errOut(err.Error())
+		return false
+	}
+	return true
+}
+
+// errOut reports the cli error m
+func errOut(m string) {
+	newUI(nil, nil, getColorUI()).errOut(m)
+}
+
+// errExit reports the cli error m and exits [cli only, never below Config.parseCmd]
 func errExit(m string) {
 	errOut(m)
 	os.Exit(1)
 }
 
-func errsyntax(m string) {
+// errsyntax reports the cli syntax error m and exits [cli only]
+func (c *Config) errsyntax(m string) {
+	u := c.ui()
 	if m != "" {
-		if getColorUI() {
-			aON, cOFF = _Red, _Off
-		}
-		out(aON + "ERROR: " + m + cOFF)
+		u.errOut(m)
 	}
-	syntaxUI()
-	usage()
-	version()
+	u.syntaxUI()
+	u.usage()
+	u.version()
 	os.Exit(1)
 }
 
@@ -301,6 +426,7 @@ const (
 	_Total     = _Yelllow + _total + _Off
 	_Owner     = _Yelllow + _owner + _Off
 	_Signifyid = _Yelllow + _signifyid + _Off
+	_TrustLvl  = _Yelllow + _trust + _Off
 	_Tag       = _Yelllow + _tag + _Off
 	_File      = _Yelllow + _file + _Off
 	_Ts        = _Yelllow + _ts + _Off
@@ -308,6 +434,10 @@ const (
 	_Ffail     = _Yelllow + _ffail + _Off
 	_Fok       = _Yelllow + _fok + _Off
 	_Fnew      = _Yelllow + _fnew + _Off
+	_Fskipped  = _Yelllow + _fskipped + _Off
+	_Fhashed   = _Yelllow + _fhashed + _Off
+	_Frestored = _Yelllow + _frestored + _Off
+	_Fwatch    = _Yelllow + _fwatch + _Off
 	_Files     = _Yelllow + _files + _Off
 	_Errc      = _Yelllow + _errc + _Off
 	_Exp       = _Yelllow + _exp + _Off
@@ -316,13 +446,73 @@ const (
 	_Ccalc     = _Yelllow + _ccalc + _Off
 )
 
-var (
-	add, signifyid                                        = "", _signifyid
-	cOFF, aON, bON, cON, gON, eON, rON, mON, wON, yON     = "", "", "", "", "", "", "", "", "", ""
-	files, file, fail, ffail, fok, fnew, owner, ts, valid = _files, _file, _fail, _ffail, _fok, _fnew, _owner, _ts, _valid
-	errc, exp, calc, cexp, ccalc                          = _errc, _exp, _calc, _cexp, _ccalc
-	total, tag, stat, unlock, lock                        = _total, _tag, _stat, _unlock, _lock
-)
+// meta data drift messages [verifyMap reason - _reasonType]
+var _errDrift = [...]string{_errFileType, _errFileSymlink, _errFileMode, _errFileOwner}
+
+// ui is the report output of one action [writers, color labels], every action owns its ui,
+// concurrent library calls never share report state
+type ui struct {
+	w, e  io.Writer // reports [stdout], prompts and diagnostics [stderr]
+	color bool
+	// ansi color codes [empty: no color]
+	cOFF, aON, bON, cON, gON, eON, rON, mON, wON, yON string
+	// [colored] labels
+	files, file, fail, ffail, fok, fnew, owner, ts, valid string
+	signifyid, trust, errc, exp, calc, cexp, ccalc        string
+	fskipped, fhashed, frestored, fwatch                  string
+	total, tag, stat, unlock, lock                        string
+}
+
+// newUI returns the report output for w and e [nil: os.Stdout, os.Stderr]
+func newUI(w, e io.Writer, color bool) *ui {
+	if w == nil {
+		w = os.Stdout
+	}
+	if e == nil {
+		e = os.Stderr
+	}
+	u := &ui{
+		w: w, e: e, color: color,
+		files: _files, file: _file, fail: _fail, ffail: _ffail, fok: _fok, fnew: _fnew, owner: _owner, ts: _ts, valid: _valid,
+		signifyid: _signifyid, trust: _trust, errc: _errc, exp: _exp, calc: _calc, cexp: _cexp, ccalc: _ccalc,
+		fskipped: _fskipped, fhashed: _fhashed, frestored: _frestored, fwatch: _fwatch,
+		total: _total, tag: _tag, stat: _stat, unlock: _unlock, lock: _lock,
+	}
+	if !color {
+		return u
+	}
+	u.cOFF, u.aON, u.bON, u.cON, u.gON, u.eON, u.rON, u.mON, u.wON, u.yON = _Off, _Red, _Blue, _Cyan, _Green, _Grey, _Red, _Magenta, _White, _Yelllow
+	u.files, u.file, u.fail, u.ffail, u.fok, u.fnew, u.owner, u.ts, u.valid = _Files, _File, _Fail, _Ffail, _Fok, _Fnew, _Owner, _Ts, _Valid
+	u.signifyid, u.trust, u.errc, u.exp, u.calc, u.cexp, u.ccalc = _Signifyid, _TrustLvl, _Errc, _Exp, _Calc, _Cexp, _Ccalc
+	u.fskipped, u.fhashed, u.frestored, u.fwatch = _Fskipped, _Fhashed, _Frestored, _Fwatch
+	u.total, u.tag, u.stat, u.unlock, u.lock = _Total, _Tag, _Stat, _Unlock, _Lock
+	return u
+}
+
+// ui returns the report output of c [Config.Stdout, Config.Stderr]
+func (c *Config) ui() *ui {
+	return newUI(c.Stdout, c.Stderr, getColorUI())
+}
+
+// out ...
+func (u *ui) out(msg string) {
+	io.WriteString(u.w, msg+_linefeedS)
+}
+
+// outPlain ...
+func (u *ui) outPlain(msg string) {
+	io.WriteString(u.w, msg)
+}
+
+// prompt writes the interactive message msg [prompts, echo] to the diagnostics writer
+func (u *ui) prompt(msg string) {
+	io.WriteString(u.e, msg)
+}
+
+// errOut writes the diagnostic m to the diagnostics writer
+func (u *ui) errOut(m string) {
+	u.prompt(u.aON + "ERROR: " + m + u.cOFF + _linefeedS)
+}
 
 func getColorUI() bool {
 	if _forceNoColor {
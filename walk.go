@@ -0,0 +1,345 @@
+package hq
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// const
+const _mapWalk = "#walk "
+
+// walkOptions ...
+type walkOptions struct {
+	follow    bool // follow symbolic links to directories [loop safe]
+	oneFS     bool // do not descend into other file systems [mount points]
+	maxDepth  int  // max depth of the recorded entries [0: unlimited, 1: top level entries only]
+	noSpecial bool // skip special files [pipe|socket|device]
+}
+
+// walkOptions ...
+func (c *Config) walkOptions() walkOptions {
+	return walkOptions{follow: c.Follow, oneFS: c.OneFS, maxDepth: c.MaxDepth, noSpecial: c.NoSpecial}
+}
+
+// encode returns the #walk header line [empty for the defaults]
+func (o walkOptions) encode() string {
+	var f []string
+	if o.follow {
+		f = append(f, "follow")
+	}
+	if o.oneFS {
+		f = append(f, "one-fs")
+	}
+	if o.maxDepth > 0 {
+		f = append(f, "max-depth="+strconv.Itoa(o.maxDepth))
+	}
+	if o.noSpecial {
+		f = append(f, "no-special")
+	}
+	if len(f) == 0 {
+		return ""
+	}
+	return _mapWalk + strings.Join(f, _space) + _linefeedS
+}
+
+// mapWalk returns the walk options recorded in the header of a decompressed .hqMAP,
+// unknown options fail closed
+func mapWalk(data []byte) (walkOptions, error) {
+	var o walkOptions
+	record, _, _ := bytes.Cut(data, []byte("\n\n"))
+	f := strings.Split(string(record), _linefeedS)
+	if f[0] != _mapHeader || !isMapRecord(f) {
+		return o, nil
+	}
+	for _, line := range f[1:] {
+		if !strings.HasPrefix(line, _mapWalk) {
+			continue
+		}
+		for field := range strings.FieldsSeq(line[len(_mapWalk):]) {
+			key, value, _ := strings.Cut(field, "=")
+			var err error
+			switch key {
+			case "follow":
+				o.follow = true
+			case "one-fs":
+				o.oneFS = true
+			case "max-depth":
+				o.maxDepth, err = strconv.Atoi(value)
+			case "no-special":
+				o.noSpecial = true
+			default:
+				err = errors.New("unknown option")
+			}
+			if err != nil {
+				return o, fmt.Errorf("%w [input map is corrupt, walk option: %s]", ErrCorruptContainer, field)
+			}
+		}
+	}
+	return o, nil
+}
+
+// cmpWalk compares the walk paths a and b in walk order [per directory sorted by name, depth
+// first], equals a byte wise compare with the path separator as lowest byte
+func cmpWalk(a, b string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		switch x, y := a[i], b[i]; {
+		case x == y:
+		case x == '/':
+			return -1
+		case y == '/':
+			return 1
+		default:
+			return cmp.Compare(x, y)
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// walker lists a directory tree in sorted depth first order [parallel read ahead]
+type walker struct {
+	walkOptions
+	root string        // signed directory [depth 0]
+	skip string        // excluded .hqMAP directory below the root [walk path, --map-out|--map-in]
+	ign  *ignoreRules  // nested .hqignore files are loaded in walk order [sign]
+	dev  uint64        // root file system [one-fs]
+	sem  chan struct{} // parallel directory reads
+}
+
+// dirID ...
+type dirID struct {
+	dev uint64
+	ino uint64
+}
+
+// listing is a [read ahead] directory listing
+type listing struct {
+	dir  string
+	done chan struct{} // nil: no free thread, read on wait
+	list []fs.DirEntry
+	err  error
+}
+
+// newWalker ...
+func newWalker(root string, o walkOptions, threads int, ign *ignoreRules) *walker {
+	if threads < 1 {
+		threads = runtime.NumCPU()
+	}
+	w := &walker{walkOptions: o, root: root, ign: ign, sem: make(chan struct{}, threads)}
+	if fi, err := os.Stat(root); err == nil {
+		w.dev, _, _ = statDev(fi)
+	}
+	return w
+}
+
+// excludeDir excludes the .hqMAP directory dir if it is a sub directory of the root, maps and
+// the .hqCACHE are never recorded [a map directory equal to the root: see admits]
+func (w *walker) excludeDir(dir string) *walker {
+	root, err := filepath.Abs(w.root)
+	if err != nil {
+		return w
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return w
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+		return w
+	}
+	w.skip = fixPath(w.root) + filepath.ToSlash(rel)
+	return w
+}
+
+// excluded reports if dir is [within] the excluded .hqMAP directory
+func (w *walker) excluded(dir string) bool {
+	return w.skip != "" && (dir == w.skip || strings.HasPrefix(dir, w.skip+"/"))
+}
+
+// readAhead starts reading the listing of dir if a thread is free
+func (w *walker) readAhead(dir string) *listing {
+	l := &listing{dir: dir}
+	select {
+	case w.sem <- struct{}{}:
+		l.done = make(chan struct{})
+		go func() {
+			l.list, l.err = os.ReadDir(dir)
+			<-w.sem
+			close(l.done)
+		}()
+	default:
+	}
+	return l
+}
+
+// wait ...
+func (l *listing) wait() ([]fs.DirEntry, error) {
+	if l.done == nil {
+		return os.ReadDir(l.dir)
+	}
+	<-l.done
+	return l.list, l.err
+}
+
+// depth returns the depth of name below the root [root: 0]
+func (w *walker) depth(name string) int {
+	rel, ok := strings.CutPrefix(name, fixPath(w.root))
+	if !ok || name == w.root || rel == "" {
+		return 0
+	}
+	return strings.Count(rel, "/") + 1
+}
+
+// descends reports if the walker enters the directory dir [ignore rules, max depth, one-fs]
+func (w *walker) descends(dir string) bool {
+	if w.excluded(dir) || w.ign.skip(dir, true) || (w.maxDepth > 0 && w.depth(dir) >= w.maxDepth) {
+		return false
+	}
+	if !w.oneFS {
+		return true
+	}
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return false
+	}
+	dev, _, ok := statDev(fi)
+	return !ok || dev == w.dev
+}
+
+// selects reports if the walker records the [non directory] entry name
+func (w *walker) selects(name string) bool {
+	lfi, err := os.Lstat(name)
+	if err != nil || lfi.IsDir() || w.ign.skipPath(name) {
+		return false
+	}
+	if w.follow && lfi.Mode()&fs.ModeSymlink != 0 && isDir(name) {
+		return false // followed directory
+	}
+	depth := w.depth(name)
+	if depth > 1 && !w.descends(path.Dir(name)) {
+		return false
+	}
+	return w.admits(name, lfi.Mode().Type(), depth-1)
+}
+
+// admits reports if the entry name of type typ within a directory of depth is recorded
+func (w *walker) admits(name string, typ fs.FileMode, depth int) bool {
+	switch base := path.Base(name); {
+	case depth == 0 && (isMapFile(base) || base == _signCache):
+		return false
+	case w.noSpecial && typ&(fs.ModeNamedPipe|fs.ModeSocket|fs.ModeDevice|fs.ModeCharDevice|fs.ModeIrregular) != 0:
+		return false
+	}
+	return !w.ign.skip(name, false)
+}
+
+// walk calls fn for every recorded entry below dir [root or one of its sub directories], an
+// unreadable sub directory is recorded as an entry itself [err: listing error]
+func (w *walker) walk(ctx context.Context, dir string, fn func(name string, err error) error) error {
+	list, err := readDir(dir)
+	if err != nil {
+		return err
+	}
+	ancestors := make(map[dirID]bool)
+	if fi, err := os.Stat(dir); err == nil {
+		if dev, ino, ok := statDev(fi); ok {
+			ancestors[dirID{dev, ino}] = true
+		}
+	}
+	return w.walkDir(ctx, dir, w.depth(dir), list, ancestors, fn)
+}
+
+// list returns all recorded entries below dir [including unreadable sub directories]
+func (w *walker) list(ctx context.Context, dir string) ([]string, error) {
+	var names []string
+	err := w.walk(ctx, dir, func(name string, _ error) error {
+		names = append(names, name)
+		return nil
+	})
+	return names, err
+}
+
+// walkDir ...
+func (w *walker) walkDir(ctx context.Context, dir string, depth int, list []fs.DirEntry, ancestors map[dirID]bool, fn func(name string, err error) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	w.ign.enter(dir)
+	type entry struct {
+		name string
+		dir  bool
+		id   dirID
+		sub  *listing
+	}
+	entries := make([]entry, 0, len(list))
+	for _, item := range list {
+		name := fixPath(dir) + item.Name()
+		typ := item.Type()
+		e := entry{name: name}
+		if typ&fs.ModeSymlink != 0 && w.follow {
+			if fi, err := os.Stat(name); err == nil && fi.IsDir() {
+				if dev, ino, ok := statDev(fi); ok && !ancestors[dirID{dev, ino}] {
+					typ, e.id = fs.ModeDir, dirID{dev, ino}
+				}
+			}
+		}
+		switch {
+		case typ&fs.ModeDir != 0:
+			if name == w.skip || w.ign.skip(name, true) || (w.maxDepth > 0 && depth+1 >= w.maxDepth) {
+				continue
+			}
+			if e.id == (dirID{}) && (w.follow || w.oneFS) {
+				if fi, err := item.Info(); err == nil {
+					e.id.dev, e.id.ino, _ = statDev(fi)
+				}
+			}
+			if w.oneFS && e.id != (dirID{}) && e.id.dev != w.dev {
+				continue
+			}
+			e.dir = true
+		case !w.admits(name, typ, depth):
+			continue
+		}
+		entries = append(entries, e)
+	}
+	for i := range entries {
+		if entries[i].dir {
+			entries[i].sub = w.readAhead(entries[i].name)
+		}
+	}
+	for _, e := range entries {
+		if !e.dir {
+			if err := fn(e.name, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		list, err := e.sub.wait()
+		if err != nil {
+			if err := fn(e.name, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if w.follow {
+			ancestors[e.id] = true
+		}
+		err = w.walkDir(ctx, e.name, depth+1, list, ancestors, fn)
+		if w.follow {
+			delete(ancestors, e.id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
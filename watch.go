@@ -0,0 +1,314 @@
+package hq
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// const
+const (
+	_watchDebounce = 100 * time.Millisecond
+	_jsonRestored  = "restored"
+	_jsonWatching  = "watching"
+	_jsonWatchErr  = "error"
+)
+
+// watchCheck compares the current state of name with its signed .hqMAP entry [ok: not signed]
+func watchCheck(name string, e mapEntry, ok bool) []WatchEvent {
+	if ok && e.meta.err == _reasonRemoved.String() {
+		ok = false // removed while signing
+	}
+	if !ok {
+		if _, err := os.Lstat(name); err != nil {
+			return nil // unsigned entry is gone
+		}
+		return []WatchEvent{{File: name, Reason: _jsonNew}}
+	}
+	if e.meta.err != "" {
+		return []WatchEvent{{File: name, Reason: _reasonNotSigned.String(), Expected: e.meta.err}}
+	}
+	var events []WatchEvent
+	fail := func(reason reasonCode, exp, found string) {
+		events = append(events, WatchEvent{File: name, Reason: reason.String(), Expected: exp, Found: found})
+	}
+	if e.hasMeta {
+		if cur, ok := statMeta(name); ok {
+			for _, d := range e.meta.drift(cur) {
+				fail(d.reason, d.exp, d.found)
+			}
+		}
+	}
+	hash, _, reason := hashFile(name)
+	switch {
+	case reason == _reasonRemoved:
+		return []WatchEvent{{File: name, Reason: _reasonRemoved.String()}}
+	case reason != _hashOK:
+		fail(reason, "", "")
+	case hash != e.hash:
+		fail(_reasonModified, e.hash, hash)
+	}
+	return events
+}
+
+// lstatDir reports if name is a directory [symbolic links are not followed]
+func lstatDir(name string) bool {
+	fi, err := os.Lstat(name)
+	return err == nil && fi.IsDir()
+}
+
+// watchKey ...
+func watchKey(events []WatchEvent) string {
+	var s string
+	for _, e := range events {
+		s += e.Reason + _space + e.Found + _linefeedS
+	}
+	return s
+}
+
+// watch verifies the signed .hqMAP of the directory c.FileName, then reports every
+// deviation [and its restore] to fn till ctx is done
+func (c *Config) watch(ctx context.Context, fn func(WatchEvent)) error {
+	id := NewHQ(c)
+	id.IO.DirName = c.FileName
+	id.IO.ReportValid = true
+	if err := id.pinKey(c); err != nil {
+		return err
+	}
+	mapName, err := c.getMap()
+	if err != nil {
+		return err
+	}
+	id.IO.FileName = mapName + _extSignature
+	data, err := decompressReadFile(mapName)
+	if err != nil {
+		return err
+	}
+	signed, err := parseMap(data, c.FileName)
+	if err != nil {
+		return err
+	}
+	ign, err := decodeIgnoreRules(c.FileName, mapRules(data))
+	if err != nil {
+		return err
+	}
+	opt, err := mapWalk(data)
+	if err != nil {
+		return err
+	}
+	sel, err := newSelectRules(c.FileName, c.Only)
+	if err != nil {
+		return err
+	}
+	if err = id.parseSig(c); err != nil {
+		return err
+	}
+	if id.IO.IsExec {
+		return fmt.Errorf("%w [%s]", ErrCorruptContainer, id.IO.FileName)
+	}
+	id.IO.ReportValid = false
+	id.IO.Start = time.Now()
+	if err = id.verifySigs(c.FileName); err != nil {
+		return err
+	}
+
+	// subscribe before the initial scan, no change is lost in between
+	walk := newWalker(c.FileName, opt, id.IO.WalkThreads, ign).excludeDir(c.mapDir())
+	w, err := newWatcher(c.FileName, func(dir string) bool { return !walk.descends(dir) })
+	if err != nil {
+		return err
+	}
+	defer w.close()
+
+	// single reporter, fn is never called concurrently
+	var waitReport, waitWorker sync.WaitGroup
+	chanReport := make(chan WatchEvent, 100)
+	waitReport.Go(func() {
+		for e := range chanReport {
+			fn(e)
+		}
+	})
+
+	// worker, each name is owned by exactly one worker [its reported state]
+	shards := make([]chan string, id.IO.CPU)
+	for i := range shards {
+		shards[i] = make(chan string, 1000)
+		waitWorker.Go(func() {
+			state := make(map[string]string)
+			for name := range shards[i] {
+				e, ok := signed[name]
+				events := watchCheck(name, e, ok)
+				key := watchKey(events)
+				if key == state[name] {
+					continue
+				}
+				now := time.Now()
+				if key == "" {
+					delete(state, name)
+					if ok {
+						chanReport <- WatchEvent{Time: now, File: name, Reason: _jsonRestored}
+					}
+					continue
+				}
+				state[name] = key
+				for _, e := range events {
+					e.Time = now
+					chanReport <- e
+				}
+			}
+		})
+	}
+	dispatch := func(name string) {
+		h := fnv.New32a()
+		h.Write([]byte(name))
+		shards[h.Sum32()%uint32(len(shards))] <- name
+	}
+
+	// signed directories [parents of all signed entries]
+	dirs := make(map[string]bool)
+	for name := range signed {
+		for i := strings.LastIndexByte(name, '/'); i > 0; i = strings.LastIndexByte(name[:i], '/') {
+			dirs[name[:i]] = true
+		}
+	}
+
+	// relevant reports if name is a signed entry or a new entry within the selection
+	relevant := func(name string) bool {
+		if !sel.selected(name) {
+			return false
+		}
+		if _, ok := signed[name]; ok {
+			return true
+		}
+		return walk.selects(name)
+	}
+
+	// expand resolves a directory [created, removed, moved] into its [signed|current] entries
+	expand := func(name string, pending map[string]bool) {
+		if dirs[name] || name == strings.TrimSuffix(c.FileName, "/") {
+			prefix := name + "/"
+			if name == strings.TrimSuffix(c.FileName, "/") {
+				prefix = fixPath(c.FileName)
+			}
+			for entry := range signed {
+				if strings.HasPrefix(entry, prefix) {
+					pending[entry] = true
+				}
+			}
+		}
+		if lstatDir(name) && walk.descends(name) {
+			current, _ := walk.list(ctx, name)
+			for _, entry := range current {
+				pending[entry] = true
+			}
+		}
+	}
+
+	// full scan [initial, event queue overflow], walk errors are reported as events
+	rescan := func(pending map[string]bool) {
+		for name := range signed {
+			pending[name] = true
+		}
+		current, err := walk.list(ctx, c.FileName)
+		if err != nil {
+			chanReport <- WatchEvent{Time: time.Now(), File: c.FileName, Reason: _jsonWatchErr, Error: err.Error()}
+		}
+		for _, name := range current {
+			pending[name] = true
+		}
+	}
+
+	// debounce events, dispatch every changed name once per tick
+	chanNames := make(chan string, 1000)
+	chanErr := make(chan error, 1)
+	go func() {
+		chanErr <- w.run(ctx, chanNames)
+		close(chanNames)
+	}()
+	pending := make(map[string]bool)
+	rescan(pending)
+	chanReport <- WatchEvent{Time: time.Now(), File: c.FileName, Reason: _jsonWatching}
+	tick := time.NewTicker(_watchDebounce)
+	defer tick.Stop()
+	for open := true; open; {
+		select {
+		case name, ok := <-chanNames:
+			switch {
+			case !ok:
+				open = false
+			case name == "":
+				rescan(pending)
+			default:
+				pending[name] = true
+			}
+			continue
+		case <-tick.C:
+		}
+		for name := range pending {
+			if _, ok := signed[name]; !ok {
+				expand(name, pending)
+			}
+		}
+		names := make([]string, 0, len(pending))
+		for name := range pending {
+			if relevant(name) {
+				names = append(names, name)
+			}
+		}
+		clear(pending)
+		slices.Sort(names)
+		for _, name := range names {
+			dispatch(name)
+		}
+	}
+	for _, shard := range shards {
+		close(shard)
+	}
+	waitWorker.Wait()
+	close(chanReport)
+	waitReport.Wait()
+	return <-chanErr
+}
+
+// runWatch ...
+func (c *Config) runWatch(ctx context.Context) error {
+	id := NewHQ(c)
+	u := id.ui
+	return c.watch(ctx, func(e WatchEvent) {
+		if c.JSON {
+			typ := "file"
+			if e.Reason == _jsonWatching || e.Reason == _jsonWatchErr {
+				typ = "watch"
+			}
+			u.out(jsonLine(jsonFile{
+				Type:     typ,
+				File:     e.File,
+				Reason:   e.Reason,
+				Expected: e.Expected,
+				Found:    e.Found,
+				Time:     e.Time.UTC().Format(time.RFC3339Nano),
+				Error:    e.Error,
+			}))
+			return
+		}
+		stamp := u.ts + e.Time.Format(time.RFC3339) + u.cOFF + _linefeedS
+		switch e.Reason {
+		case _jsonWatching:
+			u.out(stamp + u.fwatch + e.File + u.cOFF)
+		case _jsonNew:
+			u.out(stamp + u.fnew + e.File + u.cOFF)
+		case _jsonRestored:
+			u.out(stamp + u.frestored + e.File + u.cOFF)
+		case _jsonWatchErr:
+			u.out(stamp + u.aON + "ERROR: " + e.File + " [" + e.Error + "]" + u.cOFF)
+		default:
+			f := failed{filename: e.File, reason: parseReason(e.Reason), exp: e.Expected, calc: e.Found}
+			u.out(stamp + strings.TrimSuffix(id.reportFail(f), _linefeedS))
+		}
+	})
+}
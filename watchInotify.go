@@ -0,0 +1,140 @@
+//go:build linux
+
+package hq
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// inotify event mask [content, meta data, directory entries]
+const _watchMask = syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE | syscall.IN_ATTRIB | syscall.IN_CREATE |
+	syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+// watcher ...
+type watcher struct {
+	fd   int               // inotify
+	ep   int               // epoll
+	wd   map[int]string    // watch descriptor -> directory
+	skip func(string) bool // excluded directories
+}
+
+// newWatcher subscribes to dir and all its sub directories, skip excludes directories
+func newWatcher(dir string, skip func(dir string) bool) (*watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify init [%w]", err)
+	}
+	ep, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("epoll create [%w]", err)
+	}
+	w := &watcher{fd: fd, ep: ep, wd: make(map[int]string), skip: skip}
+	ev := syscall.EpollEvent{Events: syscall.EPOLLIN, Fd: int32(fd)}
+	if err := syscall.EpollCtl(ep, syscall.EPOLL_CTL_ADD, fd, &ev); err != nil {
+		w.close()
+		return nil, fmt.Errorf("epoll ctl [%w]", err)
+	}
+	if err := w.add(dir, nil); err != nil {
+		w.close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// add watches dir and all its sub directories, entries found are sent to names [nil: initial setup]
+func (w *watcher) add(dir string, names chan<- string) error {
+	wd, err := syscall.InotifyAddWatch(w.fd, dir, _watchMask)
+	switch {
+	case errors.Is(err, syscall.ENOENT), errors.Is(err, syscall.ENOTDIR):
+		return nil // removed meanwhile
+	case err != nil:
+		return fmt.Errorf("inotify watch [%s] [%w]", dir, err)
+	}
+	w.wd[wd] = dir
+	list, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	for _, item := range list {
+		name := fixPath(dir) + item.Name()
+		switch {
+		case item.IsDir():
+			if w.skip(name) {
+				continue
+			}
+			if err := w.add(name, names); err != nil {
+				return err
+			}
+		case names != nil:
+			names <- name
+		}
+	}
+	return nil
+}
+
+// run sends the walk path of every changed entry to names till ctx is done,
+// an empty name requests a full rescan [event queue overflow]
+func (w *watcher) run(ctx context.Context, names chan<- string) error {
+	buf := make([]byte, 1<<16)
+	events := make([]syscall.EpollEvent, 1)
+	for ctx.Err() == nil {
+		n, err := syscall.EpollWait(w.ep, events, 250)
+		switch {
+		case errors.Is(err, syscall.EINTR):
+			continue
+		case err != nil:
+			return fmt.Errorf("epoll wait [%w]", err)
+		case n == 0:
+			continue
+		}
+		n, err = syscall.Read(w.fd, buf)
+		switch {
+		case errors.Is(err, syscall.EAGAIN), errors.Is(err, syscall.EINTR):
+			continue
+		case err != nil:
+			return fmt.Errorf("inotify read [%w]", err)
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			wd := int(int32(binary.NativeEndian.Uint32(buf[off:])))
+			mask := binary.NativeEndian.Uint32(buf[off+4:])
+			l := int(binary.NativeEndian.Uint32(buf[off+12:]))
+			raw := buf[off+syscall.SizeofInotifyEvent : off+syscall.SizeofInotifyEvent+l]
+			off += syscall.SizeofInotifyEvent + l
+			dir, ok := w.wd[wd]
+			switch {
+			case mask&syscall.IN_Q_OVERFLOW != 0:
+				names <- ""
+				continue
+			case !ok:
+				continue
+			case mask&syscall.IN_IGNORED != 0:
+				delete(w.wd, wd)
+				continue
+			case mask&(syscall.IN_DELETE_SELF|syscall.IN_MOVE_SELF) != 0:
+				names <- dir
+				continue
+			}
+			name := fixPath(dir) + strings.TrimRight(string(raw), "\x00")
+			if mask&syscall.IN_ISDIR != 0 && mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 && !w.skip(name) {
+				if err := w.add(name, names); err != nil {
+					return err
+				}
+			}
+			names <- name
+		}
+	}
+	return nil
+}
+
+// close ...
+func (w *watcher) close() {
+	syscall.Close(w.ep)
+	syscall.Close(w.fd)
+}
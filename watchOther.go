@@ -0,0 +1,22 @@
+//go:build !linux
+
+package hq
+
+import (
+	"context"
+	"errors"
+)
+
+// watcher is not available [inotify]
+type watcher struct{}
+
+// newWatcher ...
+func newWatcher(_ string, _ func(dir string) bool) (*watcher, error) {
+	return nil, errors.New("watch mode requires inotify [linux]")
+}
+
+// run ...
+func (w *watcher) run(_ context.Context, _ chan<- string) error { return nil }
+
+// close ...
+func (w *watcher) close() {}
@@ -0,0 +1,111 @@
+//go:build linux
+
+package hq
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// testWatchEvents returns the events received till events are quiet for d
+func testWatchEvents(events <-chan WatchEvent, d time.Duration) []WatchEvent {
+	var list []WatchEvent
+	for {
+		select {
+		case e := <-events:
+			list = append(list, e)
+		case <-time.After(d):
+			return list
+		}
+	}
+}
+
+// testWatchFind returns the events of name
+func testWatchFind(list []WatchEvent, name string) []WatchEvent {
+	var found []WatchEvent
+	for _, e := range list {
+		if e.File == name {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+func TestWatchRescanDebounce(t *testing.T) {
+	c := testConfig(t)
+	c.FileName = t.TempDir()
+	dir := fixPath(c.FileName)
+	if err := os.MkdirAll(dir+"sub", 0o700); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "sub/b"} {
+		if err := os.WriteFile(dir+name, []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := SignDir(context.Background(), c); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(dir + "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// changed before the watch starts: reported by the initial scan
+	if err := os.WriteFile(dir+"sub/b", []byte("changed"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sub", "n"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan WatchEvent, 100)
+	done := make(chan error, 1)
+	go func() { done <- Watch(ctx, c, func(e WatchEvent) { events <- e }) }()
+	list := testWatchEvents(events, time.Second)
+	if len(testWatchFind(list, c.FileName)) == 0 {
+		t.Fatalf("no watching event: %+v", list)
+	}
+	if e := testWatchFind(list, dir+"sub/b"); len(e) != 1 || e[0].Reason != _reasonModified.String() {
+		t.Fatalf("initial scan, modified file: %+v", list)
+	}
+	if e := testWatchFind(list, dir+"sub/n"); len(e) != 1 || e[0].Reason != _jsonNew {
+		t.Fatalf("initial scan, new file: %+v", list)
+	}
+
+	// a burst of writes [shorter than a debounce tick] is rehashed once or twice, not once per write
+	const writes = 20
+	for i := range writes {
+		if err := os.WriteFile(dir+"a", []byte("x"+strconv.Itoa(i)), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(_watchDebounce / (2 * writes))
+	}
+	list = testWatchFind(testWatchEvents(events, time.Second), dir+"a")
+	if len(list) == 0 || len(list) > 2 {
+		t.Fatalf("burst of %d writes reported %d times", writes, len(list))
+	}
+	final, _, _ := hashFile(dir + "a")
+	if e := list[len(list)-1]; e.Reason != _reasonModified.String() || e.Found != final {
+		t.Fatalf("burst: last event %+v", e)
+	}
+
+	// back to the signed state
+	if err := os.WriteFile(dir+"a", []byte("a"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(dir+"a", fi.ModTime(), fi.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if e := testWatchFind(testWatchEvents(events, time.Second), dir+"a"); len(e) != 1 || e[0].Reason != _jsonRestored {
+		t.Fatalf("restored file: %+v", e)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}